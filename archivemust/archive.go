@@ -0,0 +1,315 @@
+// Package archivemust provides the extraction and creation of archives shared by its subpackages,
+// which wrap the archive packages with panicking error handling.
+package archivemust
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"iter"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/Jumpaku/go-mustd"
+	"github.com/Jumpaku/go-mustd/iomust"
+)
+
+var (
+	// ErrInsecurePath is reported when an entry of an archive refers to a location outside the destination directory.
+	ErrInsecurePath = errors.New("insecure path")
+	// ErrTooLarge is reported when the content of an archive exceeds a size limit.
+	ErrTooLarge = errors.New("archive too large")
+)
+
+const (
+	// DefaultMaxFileSize is the default maximum size of a file extracted from an archive.
+	DefaultMaxFileSize = 1 << 30
+	// DefaultMaxTotalSize is the default maximum total size of the files extracted from an archive.
+	DefaultMaxTotalSize = 4 << 30
+)
+
+// ExtractOption configures an Extractor.
+type ExtractOption func(*extractOptions)
+
+type extractOptions struct {
+	maxFileSize  int64
+	maxTotalSize int64
+}
+
+// MaxFileSize returns an ExtractOption that limits the size of each extracted file to n bytes. A negative n means no limit.
+func MaxFileSize(n int64) ExtractOption {
+	return func(o *extractOptions) {
+		o.maxFileSize = n
+	}
+}
+
+// MaxTotalSize returns an ExtractOption that limits the total size of the extracted files to n bytes. A negative n means no limit.
+func MaxTotalSize(n int64) ExtractOption {
+	return func(o *extractOptions) {
+		o.maxTotalSize = n
+	}
+}
+
+// Extractor writes entries of an archive into a destination directory.
+// Entries are confined to the directory: names which are absolute or contain "..", symbolic links resolving outside the directory,
+// and writes through symbolic links are rejected with ErrInsecurePath.
+// The sizes of the extracted files are limited as configured by MaxFileSize and MaxTotalSize, and exceeding them is reported with ErrTooLarge.
+type Extractor struct {
+	root     *os.Root
+	options  extractOptions
+	total    int64
+	dirs     map[string]dirAttr
+	symlinks []string
+}
+
+// dirAttr is the attributes of an extracted directory, which are set after its content is extracted.
+type dirAttr struct {
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+// NewExtractor returns a new Extractor writing into dir, creating it if necessary. Panics if an error occurs.
+func NewExtractor(dir string, opts ...ExtractOption) *Extractor {
+	o := extractOptions{maxFileSize: DefaultMaxFileSize, maxTotalSize: DefaultMaxTotalSize}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	mustd.Must0(os.MkdirAll(dir, 0o755))
+	return &Extractor{root: mustd.Must1(os.OpenRoot(dir)), options: o, dirs: map[string]dirAttr{}}
+}
+
+// localize converts the slash-separated name of an entry into a local path.
+// Panics with ErrInsecurePath if name is not local, or refers to the directory itself unless allowRoot is true.
+func localize(name string, allowRoot bool) string {
+	clean := path.Clean(name)
+	if !fs.ValidPath(clean) || clean == "." && !allowRoot {
+		panic(fmt.Errorf("archivemust: %q: %w", name, ErrInsecurePath))
+	}
+	local, err := filepath.Localize(clean)
+	if err != nil {
+		panic(fmt.Errorf("archivemust: %q: %w", name, ErrInsecurePath))
+	}
+	return local
+}
+
+// mkdirParent creates the parent directories of name.
+func (e *Extractor) mkdirParent(name string) {
+	if parent := filepath.Dir(name); parent != "." {
+		mustd.Must0(e.root.MkdirAll(parent, 0o755))
+	}
+}
+
+// Dir creates the directory of the named entry. Its permission bits and modification time are set by Close. Panics if an error occurs.
+func (e *Extractor) Dir(name string, mode fs.FileMode, modTime time.Time) {
+	local := localize(name, true)
+	if local == "." {
+		return
+	}
+	mustd.Must0(e.root.MkdirAll(local, 0o755))
+	e.dirs[local] = dirAttr{mode: mode.Perm(), modTime: modTime}
+}
+
+// File creates the file of the named entry with the permission bits of mode, the modification time and the content read from r.
+// An existing file is replaced. Panics if an error occurs, removing the partially written file.
+func (e *Extractor) File(name string, mode fs.FileMode, modTime time.Time, r iomust.Reader) {
+	local := localize(name, false)
+	e.mkdirParent(local)
+	if err := e.root.Remove(local); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		panic(err)
+	}
+	f := mustd.Must1(e.root.OpenFile(local, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600))
+	limit := e.options.maxFileSize
+	if e.options.maxTotalSize >= 0 && (limit < 0 || e.options.maxTotalSize-e.total < limit) {
+		limit = e.options.maxTotalSize - e.total
+	}
+	src := r.Reader()
+	if limit >= 0 {
+		src = io.LimitReader(src, limit+1)
+	}
+	n, err := io.Copy(f, src)
+	e.total += n
+	if err == nil && limit >= 0 && n > limit {
+		err = fmt.Errorf("archivemust: %q: %w", name, ErrTooLarge)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = e.root.Remove(local)
+		panic(err)
+	}
+	mustd.Must0(e.root.Chmod(local, mode.Perm()))
+	mustd.Must0(e.root.Chtimes(local, modTime, modTime))
+}
+
+// Symlink creates the named entry as a symbolic link to target. Panics with ErrInsecurePath if target is absolute or refers outside the directory.
+func (e *Extractor) Symlink(name, target string) {
+	local := localize(name, false)
+	if filepath.IsAbs(target) || !filepath.IsLocal(filepath.Join(filepath.Dir(local), filepath.FromSlash(target))) {
+		panic(fmt.Errorf("archivemust: %q -> %q: %w", name, target, ErrInsecurePath))
+	}
+	e.mkdirParent(local)
+	if err := e.root.Remove(local); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		panic(err)
+	}
+	mustd.Must0(e.root.Symlink(filepath.FromSlash(target), local))
+	e.symlinks = append(e.symlinks, local)
+}
+
+// Link creates the named entry as a hard link to the previously extracted entry target. Panics if an error occurs.
+func (e *Extractor) Link(name, target string) {
+	local, localTarget := localize(name, false), localize(target, false)
+	e.mkdirParent(local)
+	if err := e.root.Remove(local); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		panic(err)
+	}
+	mustd.Must0(e.root.Link(localTarget, local))
+}
+
+// Close verifies that no symbolic link resolves outside the directory through other links, sets the attributes of the directories,
+// and closes the directory. A symbolic link resolving outside the directory is removed and panics with ErrInsecurePath.
+// Call Close only after the extraction succeeds, and Abort otherwise.
+func (e *Extractor) Close() {
+	defer e.root.Close()
+	for _, link := range e.symlinks {
+		if _, err := e.root.Stat(link); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			_ = e.root.Remove(link)
+			panic(fmt.Errorf("archivemust: %q: %w", filepath.ToSlash(link), ErrInsecurePath))
+		}
+	}
+	for dir, attr := range e.dirs {
+		mustd.Must0(e.root.Chmod(dir, attr.mode))
+		mustd.Must0(e.root.Chtimes(dir, attr.modTime, attr.modTime))
+	}
+}
+
+// Abort removes the symbolic links resolving outside the directory through other links and closes the directory
+// without setting the attributes of the directories. It is called instead of Close when the extraction fails, and never panics.
+func (e *Extractor) Abort() {
+	for _, link := range e.symlinks {
+		if _, err := e.root.Stat(link); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			_ = e.root.Remove(link)
+		}
+	}
+	_ = e.root.Close()
+}
+
+// Filter reports whether the file at the slash-separated path relative to the archived directory is archived.
+// If it returns false for a directory, the directory and its content are skipped.
+type Filter func(path string, d fs.DirEntry) bool
+
+// CreateOption configures the entries returned by Walk.
+type CreateOption func(*CreateOptions)
+
+// CreateOptions is the configuration of the entries returned by Walk.
+type CreateOptions struct {
+	// ModTime replaces the modification times of the entries unless it is zero.
+	ModTime time.Time
+	// ZeroOwner clears the user and group IDs and names of the entries in archive formats recording them.
+	ZeroOwner bool
+}
+
+// NewCreateOptions returns the CreateOptions configured by opts.
+func NewCreateOptions(opts ...CreateOption) CreateOptions {
+	var o CreateOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// FixedModTime returns a CreateOption that sets the modification times of all entries to t.
+func FixedModTime(t time.Time) CreateOption {
+	return func(o *CreateOptions) {
+		o.ModTime = t
+	}
+}
+
+// ZeroOwner returns a CreateOption that clears the user and group IDs and names of all entries.
+func ZeroOwner() CreateOption {
+	return func(o *CreateOptions) {
+		o.ZeroOwner = true
+	}
+}
+
+// Reproducible returns a CreateOption for reproducible builds, which clears the owners of all entries
+// and sets their modification times to the time given by the SOURCE_DATE_EPOCH environment variable, or 1980-01-01 UTC if it is not set.
+// Panics if SOURCE_DATE_EPOCH is not an integer.
+func Reproducible() CreateOption {
+	t := time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+	if epoch, ok := os.LookupEnv("SOURCE_DATE_EPOCH"); ok {
+		t = time.Unix(mustd.Must1(strconv.ParseInt(epoch, 10, 64)), 0).UTC()
+	}
+	return func(o *CreateOptions) {
+		FixedModTime(t)(o)
+		ZeroOwner()(o)
+	}
+}
+
+// Entry is a file to be archived.
+type Entry struct {
+	// Name is the slash-separated path relative to the archived directory.
+	Name string
+	// Path is the path of the file.
+	Path string
+	// Info describes the file, not following a symbolic link.
+	Info fs.FileInfo
+	// LinkTarget is the target of a symbolic link, or empty.
+	LinkTarget string
+	// ModTime is the modification time to be archived.
+	ModTime time.Time
+}
+
+// Open opens the file of a regular entry. Panics if an error occurs.
+func (e Entry) Open() iomust.ReadCloser {
+	return iomust.ReadCloserOf(mustd.Must1(os.Open(e.Path)))
+}
+
+// Walk returns an iterator over the directories, regular files and symbolic links in dir, excluding dir itself, in the order of filepath.WalkDir:
+// the entries of each directory are visited in lexical order, and each directory is followed by its content before its next sibling.
+// Files for which filter returns false are skipped, and a nil filter archives all files.
+// Panics during iteration if an error occurs or a file of another type is found.
+func Walk(dir string, filter Filter, o CreateOptions) iter.Seq[Entry] {
+	return func(yield func(Entry) bool) {
+		stop := errors.New("stop")
+		err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			rel := mustd.Must1(filepath.Rel(dir, p))
+			if rel == "." {
+				return nil
+			}
+			name := filepath.ToSlash(rel)
+			if filter != nil && !filter(name, d) {
+				if d.IsDir() {
+					return fs.SkipDir
+				}
+				return nil
+			}
+			info := mustd.Must1(d.Info())
+			entry := Entry{Name: name, Path: p, Info: info, ModTime: info.ModTime()}
+			switch {
+			case info.Mode().IsDir(), info.Mode().IsRegular():
+			case info.Mode()&fs.ModeSymlink != 0:
+				entry.LinkTarget = filepath.ToSlash(mustd.Must1(os.Readlink(p)))
+			default:
+				return fmt.Errorf("archivemust: %q: unsupported file type %v", name, info.Mode().Type())
+			}
+			if !o.ModTime.IsZero() {
+				entry.ModTime = o.ModTime
+			}
+			if !yield(entry) {
+				return stop
+			}
+			return nil
+		})
+		if err != stop {
+			mustd.Must0(err)
+		}
+	}
+}
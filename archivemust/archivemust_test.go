@@ -0,0 +1,124 @@
+package archivemust_test
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Jumpaku/go-mustd/archivemust"
+	"github.com/Jumpaku/go-mustd/iomust"
+)
+
+func TestExtractor(t *testing.T) {
+	modTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	t.Run("files, directories and links", func(t *testing.T) {
+		dir := t.TempDir()
+		e := archivemust.NewExtractor(dir)
+		e.Dir("./", fs.ModeDir|0o755, modTime)
+		e.Dir("ro/", fs.ModeDir|0o555, modTime)
+		e.File("ro/a.txt", 0o640, modTime, iomust.ReaderOf(strings.NewReader("alpha")))
+		e.File("deep/b.txt", 0o644, modTime, iomust.ReaderOf(strings.NewReader("beta")))
+		e.Symlink("deep/link", "../ro/a.txt")
+		e.Link("hard.txt", "deep/b.txt")
+		e.Close()
+		defer os.Chmod(filepath.Join(dir, "ro"), 0o755)
+
+		info, err := os.Stat(filepath.Join(dir, "ro"))
+		if err != nil || info.Mode().Perm() != 0o555 || !info.ModTime().Equal(modTime) {
+			t.Errorf("unexpected directory info %v, %v", info, err)
+		}
+		for name, expected := range map[string]string{"deep/link": "alpha", "hard.txt": "beta"} {
+			if content, err := os.ReadFile(filepath.Join(dir, name)); err != nil || string(content) != expected {
+				t.Errorf("unexpected content of %s: %q, %v", name, content, err)
+			}
+		}
+	})
+
+	t.Run("insecure names", func(t *testing.T) {
+		for _, name := range []string{"../a", "/a", "a/../../b", ""} {
+			func() {
+				e := archivemust.NewExtractor(t.TempDir())
+				defer e.Close()
+				defer func() {
+					err, _ := recover().(error)
+					if !errors.Is(err, archivemust.ErrInsecurePath) {
+						t.Errorf("%q: expected ErrInsecurePath, got %v", name, err)
+					}
+				}()
+				e.File(name, 0o644, modTime, iomust.ReaderOf(strings.NewReader("")))
+			}()
+		}
+	})
+
+	t.Run("too large file is removed", func(t *testing.T) {
+		dir := t.TempDir()
+		e := archivemust.NewExtractor(dir, archivemust.MaxFileSize(3))
+		defer e.Close()
+		func() {
+			defer func() {
+				err, _ := recover().(error)
+				if !errors.Is(err, archivemust.ErrTooLarge) {
+					t.Errorf("expected ErrTooLarge, got %v", err)
+				}
+			}()
+			e.File("big.txt", 0o644, modTime, iomust.ReaderOf(strings.NewReader("abcdef")))
+		}()
+		if _, err := os.Lstat(filepath.Join(dir, "big.txt")); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("expected the partial file to be removed, got %v", err)
+		}
+	})
+
+	t.Run("no size limit", func(t *testing.T) {
+		e := archivemust.NewExtractor(t.TempDir(), archivemust.MaxFileSize(-1), archivemust.MaxTotalSize(-1))
+		defer e.Close()
+		e.File("a", 0o644, modTime, iomust.ReaderOf(strings.NewReader(strings.Repeat("a", 1<<10))))
+	})
+}
+
+func TestWalk(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"b/c.txt", "a.txt", "b.txt", ".git/HEAD"} {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fixed := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	var names []string
+	for entry := range archivemust.Walk(dir, func(path string, d fs.DirEntry) bool { return path != ".git" }, archivemust.NewCreateOptions(archivemust.FixedModTime(fixed))) {
+		if !entry.ModTime.Equal(fixed) {
+			t.Errorf("unexpected modification time of %s: %v", entry.Name, entry.ModTime)
+		}
+		names = append(names, entry.Name)
+	}
+	if got := strings.Join(names, ","); got != "a.txt,b,b/c.txt,b.txt" {
+		t.Errorf("unexpected entries %s", got)
+	}
+}
+
+func TestReproducible(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		t.Setenv("SOURCE_DATE_EPOCH", "")
+		os.Unsetenv("SOURCE_DATE_EPOCH")
+		o := archivemust.NewCreateOptions(archivemust.Reproducible())
+		if !o.ZeroOwner || !o.ModTime.Equal(time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("unexpected options %+v", o)
+		}
+	})
+
+	t.Run("SOURCE_DATE_EPOCH", func(t *testing.T) {
+		t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+		o := archivemust.NewCreateOptions(archivemust.Reproducible())
+		if !o.ModTime.Equal(time.Unix(1700000000, 0)) {
+			t.Errorf("unexpected modification time %v", o.ModTime)
+		}
+	})
+}
@@ -0,0 +1,136 @@
+// Package tarmust provides wrappers for the archive/tar package with panicking error handling.
+package tarmust
+
+import (
+	"archive/tar"
+	"io"
+
+	"github.com/Jumpaku/go-mustd"
+	"github.com/Jumpaku/go-mustd/archivemust"
+	"github.com/Jumpaku/go-mustd/iomust"
+)
+
+// Reader wraps tar.Reader and provides panicking error handling.
+// It implements iomust.Reader reading the content of the current entry.
+type Reader struct {
+	reader *tar.Reader
+}
+
+// NewReader returns a new Reader reading from r.
+func NewReader(r iomust.Reader) *Reader {
+	return &Reader{reader: tar.NewReader(r.Reader())}
+}
+
+// Next advances to the next entry and returns its header. It returns false at the end of the archive. Panics if an error occurs.
+func (r *Reader) Next() (*tar.Header, bool) {
+	header, err := r.reader.Next()
+	if err == io.EOF {
+		return nil, false
+	}
+	mustd.Must0(err)
+	return header, true
+}
+
+// Read reads from the current entry. Panics if an error occurs.
+func (r *Reader) Read(p []byte) int {
+	return iomust.ReaderOf(r.reader).Read(p)
+}
+
+// Reader returns the underlying reader of the current entry.
+func (r *Reader) Reader() io.Reader {
+	return r.reader
+}
+
+// ExtractTo extracts the remaining entries into dir, creating it if necessary, and preserves their permission bits and modification times.
+// Directories, regular files, symbolic links and hard links are extracted, and the other entries are skipped.
+// Entries are confined to dir and their sizes are limited as archivemust.Extractor.
+// Panics with an error wrapping archivemust.ErrInsecurePath or archivemust.ErrTooLarge, or panics if an error occurs.
+// On a panic, the partially written file is removed, and the permission bits and modification times of the directories are not set.
+func (r *Reader) ExtractTo(dir string, opts ...archivemust.ExtractOption) {
+	e := archivemust.NewExtractor(dir, opts...)
+	done := false
+	defer func() {
+		if !done {
+			e.Abort()
+		}
+	}()
+	for {
+		header, ok := r.Next()
+		if !ok {
+			done = true
+			e.Close()
+			return
+		}
+		mode := header.FileInfo().Mode()
+		switch header.Typeflag {
+		case tar.TypeDir:
+			e.Dir(header.Name, mode, header.ModTime)
+		case tar.TypeReg:
+			e.File(header.Name, mode, header.ModTime, r)
+		case tar.TypeSymlink:
+			e.Symlink(header.Name, header.Linkname)
+		case tar.TypeLink:
+			e.Link(header.Name, header.Linkname)
+		}
+	}
+}
+
+// Writer wraps tar.Writer and provides panicking error handling.
+// It implements iomust.Writer writing the content of the current entry.
+type Writer struct {
+	writer *tar.Writer
+}
+
+// NewWriter returns a new Writer writing to w.
+func NewWriter(w iomust.Writer) *Writer {
+	return &Writer{writer: tar.NewWriter(w.Writer())}
+}
+
+// WriteHeader writes hdr and prepares to accept the content of the entry. Panics if an error occurs.
+func (w *Writer) WriteHeader(hdr *tar.Header) {
+	mustd.Must0(w.writer.WriteHeader(hdr))
+}
+
+// Write writes to the current entry. Panics if an error occurs.
+func (w *Writer) Write(p []byte) int {
+	return mustd.Must1(w.writer.Write(p))
+}
+
+// Writer returns the underlying writer of the current entry.
+func (w *Writer) Writer() io.Writer {
+	return w.writer
+}
+
+// Flush finishes writing the current entry. Panics if an error occurs.
+func (w *Writer) Flush() {
+	mustd.Must0(w.writer.Flush())
+}
+
+// Close writes the footer of the archive. It does not close the underlying writer. Panics if an error occurs.
+func (w *Writer) Close() {
+	mustd.Must0(w.writer.Close())
+}
+
+// CreateFromDir writes the directories, regular files and symbolic links in dir as entries named by their slash-separated paths relative to dir,
+// in the order of archivemust.Walk with their permission bits and modification times. Files for which filter returns false are skipped.
+// The entries can be made reproducible by archivemust.FixedModTime, archivemust.ZeroOwner or archivemust.Reproducible. Panics if an error occurs.
+func (w *Writer) CreateFromDir(dir string, filter archivemust.Filter, opts ...archivemust.CreateOption) {
+	o := archivemust.NewCreateOptions(opts...)
+	for entry := range archivemust.Walk(dir, filter, o) {
+		header := mustd.Must1(tar.FileInfoHeader(entry.Info, entry.LinkTarget))
+		header.Name = entry.Name
+		if entry.Info.IsDir() {
+			header.Name += "/"
+		}
+		header.ModTime = entry.ModTime
+		if o.ZeroOwner {
+			header.Uid, header.Gid, header.Uname, header.Gname = 0, 0, "", ""
+		}
+		w.WriteHeader(header)
+		if entry.Info.Mode().IsRegular() {
+			f := entry.Open()
+			iomust.Copy(w, f)
+			f.Close()
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package tarmust_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Jumpaku/go-mustd/archivemust"
+	"github.com/Jumpaku/go-mustd/archivemust/tarmust"
+	"github.com/Jumpaku/go-mustd/iomust"
+)
+
+var modTime = time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+// makeTree creates a directory tree to be archived and returns its path.
+func makeTree(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := []struct {
+		name    string
+		content string
+		mode    fs.FileMode
+	}{
+		{"a.txt", "alpha", 0o644},
+		{"bin/run.sh", "#!/bin/sh\n", 0o755},
+		{"debug.log", "log", 0o600},
+	}
+	for _, f := range files {
+		p := filepath.Join(dir, f.name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(f.content), f.mode); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chmod(p, f.mode); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(p, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink("a.txt", filepath.Join(dir, "link")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(filepath.Join(dir, "bin"), modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// archive writes the headers and contents as a tar archive.
+func archive(t *testing.T, entries map[*tar.Header]string, order ...*tar.Header) []byte {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	w := tarmust.NewWriter(iomust.WriterOf(buf))
+	for _, h := range order {
+		h.Size = int64(len(entries[h]))
+		w.WriteHeader(h)
+		w.Write([]byte(entries[h]))
+	}
+	w.Close()
+	return buf.Bytes()
+}
+
+func expectPanic(t *testing.T, target error, f func()) {
+	t.Helper()
+	defer func() {
+		t.Helper()
+		err, _ := recover().(error)
+		if !errors.Is(err, target) {
+			t.Errorf("expected %v, got %v", target, err)
+		}
+	}()
+	f()
+}
+
+func TestReaderWriter(t *testing.T) {
+	t.Run("Next and WriteHeader", func(t *testing.T) {
+		h := &tar.Header{Name: "hello.txt", Mode: 0o644, Typeflag: tar.TypeReg}
+		data := archive(t, map[*tar.Header]string{h: "hello"}, h)
+
+		r := tarmust.NewReader(iomust.ReaderOf(bytes.NewReader(data)))
+		header, ok := r.Next()
+		if !ok || header.Name != "hello.txt" {
+			t.Fatalf("unexpected header %+v", header)
+		}
+		if got := string(iomust.ReadAll(r)); got != "hello" {
+			t.Errorf("unexpected content %q", got)
+		}
+		if _, ok := r.Next(); ok {
+			t.Error("Next returned true at the end of the archive")
+		}
+	})
+
+	t.Run("Next panics on corrupt archive", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("Next did not panic")
+			}
+		}()
+		tarmust.NewReader(iomust.ReaderOf(strings.NewReader(strings.Repeat("x", 1024)))).Next()
+	})
+
+	t.Run("Write panics beyond the size of the entry", func(t *testing.T) {
+		w := tarmust.NewWriter(iomust.WriterOf(&bytes.Buffer{}))
+		w.WriteHeader(&tar.Header{Name: "a", Mode: 0o644, Size: 1})
+		expectPanic(t, tar.ErrWriteTooLong, func() { w.Write([]byte("ab")) })
+	})
+}
+
+func TestCreateFromDirAndExtractTo(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		src := makeTree(t)
+		buf := &bytes.Buffer{}
+		w := tarmust.NewWriter(iomust.WriterOf(buf))
+		w.CreateFromDir(src, func(path string, d fs.DirEntry) bool { return !strings.HasSuffix(path, ".log") })
+		w.Close()
+
+		dst := filepath.Join(t.TempDir(), "out")
+		tarmust.NewReader(iomust.ReaderOf(buf)).ExtractTo(dst)
+
+		if content, err := os.ReadFile(filepath.Join(dst, "bin/run.sh")); err != nil || string(content) != "#!/bin/sh\n" {
+			t.Errorf("unexpected content %q, %v", content, err)
+		}
+		info, err := os.Stat(filepath.Join(dst, "bin/run.sh"))
+		if err != nil || info.Mode().Perm() != 0o755 || !info.ModTime().Equal(modTime) {
+			t.Errorf("unexpected file info %v, %v", info, err)
+		}
+		info, err = os.Stat(filepath.Join(dst, "bin"))
+		if err != nil || info.Mode().Perm() != 0o750 || !info.ModTime().Equal(modTime) {
+			t.Errorf("unexpected directory info %v, %v", info, err)
+		}
+		if target, err := os.Readlink(filepath.Join(dst, "link")); err != nil || target != "a.txt" {
+			t.Errorf("unexpected link target %q, %v", target, err)
+		}
+		if _, err := os.Stat(filepath.Join(dst, "debug.log")); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("filtered file was archived: %v", err)
+		}
+	})
+
+	t.Run("reproducible", func(t *testing.T) {
+		create := func(src string) []byte {
+			buf := &bytes.Buffer{}
+			w := tarmust.NewWriter(iomust.WriterOf(buf))
+			w.CreateFromDir(src, nil, archivemust.FixedModTime(modTime), archivemust.ZeroOwner())
+			w.Close()
+			return buf.Bytes()
+		}
+		src := makeTree(t)
+		first := create(src)
+		if err := os.Chtimes(filepath.Join(src, "a.txt"), time.Now(), time.Now()); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(first, create(src)) {
+			t.Error("archives differ after changing a modification time")
+		}
+		r := tarmust.NewReader(iomust.ReaderOf(bytes.NewReader(first)))
+		var names []string
+		for header, ok := r.Next(); ok; header, ok = r.Next() {
+			if header.Uid != 0 || header.Uname != "" || !header.ModTime.Equal(modTime) {
+				t.Errorf("unexpected header %+v", header)
+			}
+			names = append(names, header.Name)
+		}
+		if got := strings.Join(names, ","); got != "a.txt,bin/,bin/run.sh,debug.log,link" {
+			t.Errorf("unexpected entries %s", got)
+		}
+	})
+}
+
+func TestExtractToInsecure(t *testing.T) {
+	reg := func(name string) *tar.Header {
+		return &tar.Header{Name: name, Mode: 0o644, Typeflag: tar.TypeReg}
+	}
+	symlink := func(name, target string) *tar.Header {
+		return &tar.Header{Name: name, Linkname: target, Typeflag: tar.TypeSymlink}
+	}
+	extract := func(data []byte, opts ...archivemust.ExtractOption) {
+		tarmust.NewReader(iomust.ReaderOf(bytes.NewReader(data))).ExtractTo(t.TempDir(), opts...)
+	}
+
+	for _, name := range []string{"../evil.txt", "a/../../evil.txt", "/etc/evil.txt"} {
+		t.Run("path traversal "+name, func(t *testing.T) {
+			h := reg(name)
+			data := archive(t, map[*tar.Header]string{h: "evil"}, h)
+			expectPanic(t, archivemust.ErrInsecurePath, func() { extract(data) })
+		})
+	}
+
+	t.Run("symlink escaping", func(t *testing.T) {
+		h := symlink("a/etc", "../../etc")
+		data := archive(t, nil, h)
+		expectPanic(t, archivemust.ErrInsecurePath, func() { extract(data) })
+	})
+
+	t.Run("write through symlink", func(t *testing.T) {
+		parent := t.TempDir()
+		file := reg("d/up2/evil.txt")
+		data := archive(t, map[*tar.Header]string{file: "evil"}, symlink("d/up", ".."), symlink("d/up2", "up/.."), file)
+		defer func() {
+			if recover() == nil {
+				t.Error("ExtractTo did not panic")
+			}
+			if _, err := os.Stat(filepath.Join(parent, "evil.txt")); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("file was written outside: %v", err)
+			}
+		}()
+		tarmust.NewReader(iomust.ReaderOf(bytes.NewReader(data))).ExtractTo(filepath.Join(parent, "out"))
+	})
+
+	t.Run("symlink escaping through another symlink", func(t *testing.T) {
+		data := archive(t, nil, symlink("d/up2", "up/.."), symlink("d/up", ".."))
+		dst := t.TempDir()
+		expectPanic(t, archivemust.ErrInsecurePath, func() {
+			tarmust.NewReader(iomust.ReaderOf(bytes.NewReader(data))).ExtractTo(dst)
+		})
+		if _, err := os.Lstat(filepath.Join(dst, "d/up2")); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("escaping symlink was not removed: %v", err)
+		}
+	})
+
+	t.Run("file size limit", func(t *testing.T) {
+		h := reg("big.bin")
+		data := archive(t, map[*tar.Header]string{h: strings.Repeat("0", 1000)}, h)
+		expectPanic(t, archivemust.ErrTooLarge, func() { extract(data, archivemust.MaxFileSize(999)) })
+	})
+
+	t.Run("failure partway", func(t *testing.T) {
+		dir := &tar.Header{Name: "d/", Mode: 0o500, Typeflag: tar.TypeDir, ModTime: modTime}
+		small, big := reg("d/small.bin"), reg("d/big.bin")
+		data := archive(t, map[*tar.Header]string{small: "small", big: strings.Repeat("0", 1000)}, dir, small, big)
+		out := t.TempDir()
+		expectPanic(t, archivemust.ErrTooLarge, func() {
+			tarmust.NewReader(iomust.ReaderOf(bytes.NewReader(data))).ExtractTo(out, archivemust.MaxFileSize(999))
+		})
+		if _, err := os.Stat(filepath.Join(out, "d", "big.bin")); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("partially extracted file was not removed: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(out, "d", "small.bin")); err != nil {
+			t.Errorf("extracted file is missing: %v", err)
+		}
+		info, err := os.Stat(filepath.Join(out, "d"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != 0o755 || info.ModTime().Equal(modTime) {
+			t.Errorf("directory attributes were set after the failure: %v %v", info.Mode(), info.ModTime())
+		}
+	})
+
+	t.Run("total size limit", func(t *testing.T) {
+		a, b := reg("a.bin"), reg("b.bin")
+		data := archive(t, map[*tar.Header]string{a: strings.Repeat("0", 600), b: strings.Repeat("0", 600)}, a, b)
+		expectPanic(t, archivemust.ErrTooLarge, func() { extract(data, archivemust.MaxTotalSize(1000)) })
+		extract(data, archivemust.MaxTotalSize(1200))
+	})
+}
@@ -0,0 +1,168 @@
+// Package zipmust provides wrappers for the archive/zip package with panicking error handling.
+package zipmust
+
+import (
+	"archive/zip"
+	"io"
+	"io/fs"
+
+	"github.com/Jumpaku/go-mustd"
+	"github.com/Jumpaku/go-mustd/archivemust"
+	"github.com/Jumpaku/go-mustd/iomust"
+)
+
+// maxLinkTarget is the maximum length of the target of a symbolic link read from an archive.
+const maxLinkTarget = 4096
+
+// Reader wraps zip.Reader and provides panicking error handling.
+type Reader struct {
+	reader *zip.Reader
+}
+
+// NewReader returns a new Reader reading from r, which is assumed to have the given size in bytes. Panics if an error occurs.
+func NewReader(r io.ReaderAt, size int64) *Reader {
+	return &Reader{reader: mustd.Must1(zip.NewReader(r, size))}
+}
+
+// Comment returns the comment of the archive.
+func (r *Reader) Comment() string {
+	return r.reader.Comment
+}
+
+// Files returns the entries of the archive in the order of the central directory.
+func (r *Reader) Files() []*zip.File {
+	return r.reader.File
+}
+
+// FS returns the archive as a read-only file system.
+func (r *Reader) FS() fs.FS {
+	return r.reader
+}
+
+// Open opens the named entry. Panics if an error occurs.
+func (r *Reader) Open(name string) iomust.ReadCloser {
+	f := mustd.Must1(r.reader.Open(name))
+	return iomust.ReadCloserOf(f)
+}
+
+// OpenFile opens the content of f, which is verified against its checksum when read until the end. Panics if an error occurs.
+func (r *Reader) OpenFile(f *zip.File) iomust.ReadCloser {
+	return iomust.ReadCloserOf(mustd.Must1(f.Open()))
+}
+
+// ExtractTo extracts the entries into dir, creating it if necessary, and preserves their permission bits and modification times.
+// Directories, regular files and symbolic links are extracted, and the other entries are skipped.
+// Entries are confined to dir and their sizes are limited as archivemust.Extractor, regardless of the sizes recorded in the archive.
+// Panics with an error wrapping archivemust.ErrInsecurePath or archivemust.ErrTooLarge, or panics if an error occurs.
+// On a panic, the partially written file is removed, and the permission bits and modification times of the directories are not set.
+func (r *Reader) ExtractTo(dir string, opts ...archivemust.ExtractOption) {
+	e := archivemust.NewExtractor(dir, opts...)
+	done := false
+	defer func() {
+		if !done {
+			e.Abort()
+		}
+	}()
+	for _, f := range r.reader.File {
+		mode := f.Mode()
+		switch {
+		case mode.IsDir():
+			e.Dir(f.Name, mode, f.Modified)
+		case mode.IsRegular():
+			content := r.OpenFile(f)
+			e.File(f.Name, mode, f.Modified, content)
+			content.Close()
+		case mode&fs.ModeSymlink != 0:
+			content := r.OpenFile(f)
+			target := iomust.ReadAll(iomust.LimitReader(content, maxLinkTarget))
+			content.Close()
+			e.Symlink(f.Name, string(target))
+		}
+	}
+	done = true
+	e.Close()
+}
+
+// ReadCloser is a Reader of a file which must be closed.
+type ReadCloser struct {
+	Reader
+	closer *zip.ReadCloser
+}
+
+// OpenReader opens the named zip file. Panics if an error occurs.
+func OpenReader(name string) *ReadCloser {
+	rc := mustd.Must1(zip.OpenReader(name))
+	return &ReadCloser{Reader: Reader{reader: &rc.Reader}, closer: rc}
+}
+
+// Close closes the file. Panics if an error occurs.
+func (rc *ReadCloser) Close() {
+	mustd.Must0(rc.closer.Close())
+}
+
+// Writer wraps zip.Writer and provides panicking error handling.
+type Writer struct {
+	writer *zip.Writer
+}
+
+// NewWriter returns a new Writer writing to w.
+func NewWriter(w iomust.Writer) *Writer {
+	return &Writer{writer: zip.NewWriter(w.Writer())}
+}
+
+// Create adds an entry of the name compressed by Deflate and returns a Writer of its content, which is valid until the next entry is added.
+// Panics if an error occurs.
+func (w *Writer) Create(name string) iomust.Writer {
+	return iomust.WriterOf(mustd.Must1(w.writer.Create(name)))
+}
+
+// CreateHeader adds an entry described by fh and returns a Writer of its content, which is valid until the next entry is added.
+// Panics if an error occurs.
+func (w *Writer) CreateHeader(fh *zip.FileHeader) iomust.Writer {
+	return iomust.WriterOf(mustd.Must1(w.writer.CreateHeader(fh)))
+}
+
+// Copy copies f, without decompressing and recompressing it, from a Reader. Panics if an error occurs.
+func (w *Writer) Copy(f *zip.File) {
+	mustd.Must0(w.writer.Copy(f))
+}
+
+// SetComment sets the comment of the archive. Panics if the comment is too long.
+func (w *Writer) SetComment(comment string) {
+	mustd.Must0(w.writer.SetComment(comment))
+}
+
+// Flush flushes buffered data to the underlying writer. Panics if an error occurs.
+func (w *Writer) Flush() {
+	mustd.Must0(w.writer.Flush())
+}
+
+// Close writes the central directory. It does not close the underlying writer. Panics if an error occurs.
+func (w *Writer) Close() {
+	mustd.Must0(w.writer.Close())
+}
+
+// CreateFromDir adds the directories, regular files and symbolic links in dir as entries named by their slash-separated paths relative to dir,
+// in the order of archivemust.Walk with their permission bits and modification times. Regular files are compressed by Deflate.
+// Files for which filter returns false are skipped.
+// The entries can be made reproducible by archivemust.FixedModTime or archivemust.Reproducible. Panics if an error occurs.
+func (w *Writer) CreateFromDir(dir string, filter archivemust.Filter, opts ...archivemust.CreateOption) {
+	for entry := range archivemust.Walk(dir, filter, archivemust.NewCreateOptions(opts...)) {
+		header := mustd.Must1(zip.FileInfoHeader(entry.Info))
+		header.Name = entry.Name
+		header.Modified = entry.ModTime.UTC()
+		switch {
+		case entry.Info.IsDir():
+			header.Name += "/"
+			w.CreateHeader(header)
+		case entry.Info.Mode().IsRegular():
+			header.Method = zip.Deflate
+			content := w.CreateHeader(header)
+			f := entry.Open()
+			iomust.Copy(content, f)
+			f.Close()
+		default:
+			iomust.WriteString(w.CreateHeader(header), entry.LinkTarget)
+		}
+	}
+}
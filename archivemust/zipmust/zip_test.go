@@ -0,0 +1,220 @@
+package zipmust_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Jumpaku/go-mustd/archivemust"
+	"github.com/Jumpaku/go-mustd/archivemust/zipmust"
+	"github.com/Jumpaku/go-mustd/iomust"
+)
+
+var modTime = time.Date(2020, 1, 2, 3, 4, 6, 0, time.UTC)
+
+// makeTree creates a directory tree to be archived and returns its path.
+func makeTree(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "bin"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	for name, mode := range map[string]fs.FileMode{"a.txt": 0o644, "bin/run.sh": 0o755} {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, []byte(name), mode); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(p, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink("../a.txt", filepath.Join(dir, "bin/link")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(filepath.Join(dir, "bin"), modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// archive writes the named contents as a zip archive.
+func archive(t *testing.T, names []string, contents ...string) []byte {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	w := zipmust.NewWriter(iomust.WriterOf(buf))
+	for i, name := range names {
+		iomust.WriteString(w.Create(name), contents[i])
+	}
+	w.Close()
+	return buf.Bytes()
+}
+
+func expectPanic(t *testing.T, target error, f func()) {
+	t.Helper()
+	defer func() {
+		t.Helper()
+		err, _ := recover().(error)
+		if !errors.Is(err, target) {
+			t.Errorf("expected %v, got %v", target, err)
+		}
+	}()
+	f()
+}
+
+func TestReaderWriter(t *testing.T) {
+	t.Run("Create and Open", func(t *testing.T) {
+		data := archive(t, []string{"hello.txt", "dir/world.txt"}, "hello", "world")
+		r := zipmust.NewReader(bytes.NewReader(data), int64(len(data)))
+		if len(r.Files()) != 2 {
+			t.Fatalf("expected 2 files, got %d", len(r.Files()))
+		}
+		f := r.Open("dir/world.txt")
+		defer f.Close()
+		if got := string(iomust.ReadAll(f)); got != "world" {
+			t.Errorf("unexpected content %q", got)
+		}
+	})
+
+	t.Run("OpenReader and Copy", func(t *testing.T) {
+		name := filepath.Join(t.TempDir(), "a.zip")
+		if err := os.WriteFile(name, archive(t, []string{"a.txt"}, "alpha"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		rc := zipmust.OpenReader(name)
+		defer rc.Close()
+
+		buf := &bytes.Buffer{}
+		w := zipmust.NewWriter(iomust.WriterOf(buf))
+		w.Copy(rc.Files()[0])
+		w.SetComment("copied")
+		w.Close()
+
+		r := zipmust.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		f := r.OpenFile(r.Files()[0])
+		defer f.Close()
+		if got := string(iomust.ReadAll(f)); got != "alpha" || r.Comment() != "copied" {
+			t.Errorf("unexpected content %q and comment %q", got, r.Comment())
+		}
+	})
+
+	t.Run("NewReader panics on invalid archive", func(t *testing.T) {
+		expectPanic(t, zip.ErrFormat, func() { zipmust.NewReader(strings.NewReader("not zip"), 7) })
+	})
+
+	t.Run("Open panics on missing entry", func(t *testing.T) {
+		data := archive(t, nil)
+		r := zipmust.NewReader(bytes.NewReader(data), int64(len(data)))
+		expectPanic(t, fs.ErrNotExist, func() { r.Open("missing.txt") })
+	})
+}
+
+func TestCreateFromDirAndExtractTo(t *testing.T) {
+	create := func(src string, filter archivemust.Filter, opts ...archivemust.CreateOption) []byte {
+		buf := &bytes.Buffer{}
+		w := zipmust.NewWriter(iomust.WriterOf(buf))
+		w.CreateFromDir(src, filter, opts...)
+		w.Close()
+		return buf.Bytes()
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+		data := create(makeTree(t), nil)
+		dst := filepath.Join(t.TempDir(), "out")
+		zipmust.NewReader(bytes.NewReader(data), int64(len(data))).ExtractTo(dst)
+
+		info, err := os.Stat(filepath.Join(dst, "bin/run.sh"))
+		if err != nil || info.Mode().Perm() != 0o755 || !info.ModTime().Equal(modTime) {
+			t.Errorf("unexpected file info %v, %v", info, err)
+		}
+		info, err = os.Stat(filepath.Join(dst, "bin"))
+		if err != nil || info.Mode().Perm() != 0o750 || !info.ModTime().Equal(modTime) {
+			t.Errorf("unexpected directory info %v, %v", info, err)
+		}
+		if content, err := os.ReadFile(filepath.Join(dst, "bin/link")); err != nil || string(content) != "a.txt" {
+			t.Errorf("unexpected content through link %q, %v", content, err)
+		}
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		data := create(makeTree(t), func(path string, d fs.DirEntry) bool { return path != "bin" })
+		r := zipmust.NewReader(bytes.NewReader(data), int64(len(data)))
+		if len(r.Files()) != 1 || r.Files()[0].Name != "a.txt" {
+			t.Errorf("unexpected entries %v", r.Files())
+		}
+	})
+
+	t.Run("reproducible", func(t *testing.T) {
+		src := makeTree(t)
+		first := create(src, nil, archivemust.FixedModTime(modTime))
+		if err := os.Chtimes(filepath.Join(src, "a.txt"), time.Now(), time.Now()); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(first, create(src, nil, archivemust.FixedModTime(modTime))) {
+			t.Error("archives differ after changing a modification time")
+		}
+	})
+}
+
+func TestExtractToInsecure(t *testing.T) {
+	extract := func(data []byte, opts ...archivemust.ExtractOption) {
+		zipmust.NewReader(bytes.NewReader(data), int64(len(data))).ExtractTo(t.TempDir(), opts...)
+	}
+
+	t.Run("path traversal", func(t *testing.T) {
+		data := archive(t, []string{"ok.txt", "../evil.txt"}, "ok", "evil")
+		expectPanic(t, archivemust.ErrInsecurePath, func() { extract(data) })
+	})
+
+	t.Run("symlink escaping", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		w := zipmust.NewWriter(iomust.WriterOf(buf))
+		header := &zip.FileHeader{Name: "etc"}
+		header.SetMode(fs.ModeSymlink | 0o777)
+		iomust.WriteString(w.CreateHeader(header), "/etc")
+		w.Close()
+		expectPanic(t, archivemust.ErrInsecurePath, func() { extract(buf.Bytes()) })
+	})
+
+	t.Run("failure partway", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		w := zipmust.NewWriter(iomust.WriterOf(buf))
+		dir := &zip.FileHeader{Name: "d/", Modified: modTime}
+		dir.SetMode(fs.ModeDir | 0o500)
+		w.CreateHeader(dir)
+		iomust.WriteString(w.Create("d/small.bin"), "small")
+		iomust.WriteString(w.Create("d/big.bin"), strings.Repeat("0", 1000))
+		w.Close()
+		out := t.TempDir()
+		expectPanic(t, archivemust.ErrTooLarge, func() {
+			zipmust.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len())).ExtractTo(out, archivemust.MaxFileSize(999))
+		})
+		if _, err := os.Stat(filepath.Join(out, "d", "big.bin")); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("partially extracted file was not removed: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(out, "d", "small.bin")); err != nil {
+			t.Errorf("extracted file is missing: %v", err)
+		}
+		info, err := os.Stat(filepath.Join(out, "d"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != 0o755 || info.ModTime().Equal(modTime) {
+			t.Errorf("directory attributes were set after the failure: %v %v", info.Mode(), info.ModTime())
+		}
+	})
+
+	t.Run("zip bomb", func(t *testing.T) {
+		data := archive(t, []string{"bomb.bin"}, strings.Repeat("\x00", 1<<20))
+		if len(data) > 1<<12 {
+			t.Fatalf("archive is not compressed: %d bytes", len(data))
+		}
+		expectPanic(t, archivemust.ErrTooLarge, func() { extract(data, archivemust.MaxFileSize(1<<16)) })
+		expectPanic(t, archivemust.ErrTooLarge, func() { extract(data, archivemust.MaxTotalSize(1<<16)) })
+	})
+}
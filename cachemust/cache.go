@@ -0,0 +1,120 @@
+// Package cachemust provides a file-based cache of gob-encoded values keyed by hashes of inputs,
+// which lets scripts skip work whose inputs did not change since the previous run.
+package cachemust
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/Jumpaku/go-mustd"
+	"github.com/Jumpaku/go-mustd/encodingmust/gobmust"
+	"github.com/Jumpaku/go-mustd/encodingmust/jsonmust"
+	"github.com/Jumpaku/go-mustd/osmust"
+)
+
+// Cache stores gob-encoded values in files of a directory.
+type Cache struct {
+	dir string
+}
+
+// New returns a Cache in the directory name under osmust.UserCacheDir. Panics if an error occurs.
+func New(name string) *Cache {
+	return NewAt(filepath.Join(osmust.UserCacheDir(), name))
+}
+
+// NewAt returns a Cache in the directory dir, creating it if necessary. Panics if an error occurs.
+func NewAt(dir string) *Cache {
+	osmust.MkdirAll(dir, 0o755)
+	return &Cache{dir: dir}
+}
+
+// Dir returns the directory of the cache.
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+// Key returns a key which is the hex-encoded SHA-256 hash of inputs and their types.
+// Strings and byte slices are hashed as the same bytes, and other values are hashed by their types and JSON encodings.
+// Panics if an error occurs.
+func Key(inputs ...any) string {
+	h := sha256.New()
+	for _, input := range inputs {
+		var kind string
+		var data []byte
+		switch input := input.(type) {
+		case string:
+			kind, data = "bytes", []byte(input)
+		case []byte:
+			kind, data = "bytes", input
+		default:
+			kind, data = fmt.Sprintf("%T", input), jsonmust.Marshal(input)
+		}
+		// Prefix each part with its length so that different inputs cannot produce the same byte sequence.
+		for _, part := range [][]byte{[]byte(kind), data} {
+			h.Write(binary.AppendUvarint(nil, uint64(len(part))))
+			h.Write(part)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Put stores the gob encoding of v for key. Panics if an error occurs.
+func (c *Cache) Put(key string, v any) {
+	gobmust.EncodeFile(c.path(key), v, 0o644)
+}
+
+// Delete removes the value for key if it exists. Panics if an error occurs.
+func (c *Cache) Delete(key string) {
+	if err := os.Remove(c.path(key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		mustd.Must0(err)
+	}
+}
+
+// Clear removes all values in the cache. Panics if an error occurs.
+func (c *Cache) Clear() {
+	for _, name := range mustd.Must1(filepath.Glob(filepath.Join(c.dir, "*.gob"))) {
+		mustd.Must0(os.Remove(name))
+	}
+}
+
+// path returns the file of the value for key, which is named by the hash of key so that any key stays in the directory.
+func (c *Cache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".gob")
+}
+
+// Get returns the value for key as a value of type T, and whether it is found.
+// A value which cannot be decoded as T, for example because T has changed since it was stored, is removed and treated as not found.
+// Panics if an error occurs reading the cache.
+func Get[T any](c *Cache, key string) (v T, ok bool) {
+	data, err := os.ReadFile(c.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return v, false
+	}
+	mustd.Must0(err)
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&v); err != nil {
+		c.Delete(key)
+		var zero T
+		return zero, false
+	}
+	return v, true
+}
+
+// GetOrPut returns the value for key as a value of type T if it is found, and otherwise stores and returns the result of compute.
+// Panics if an error occurs.
+func GetOrPut[T any](c *Cache, key string, compute func() T) T {
+	if v, ok := Get[T](c, key); ok {
+		return v
+	}
+	v := compute()
+	c.Put(key, v)
+	return v
+}
@@ -0,0 +1,111 @@
+package cachemust_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/Jumpaku/go-mustd/cachemust"
+)
+
+type result struct {
+	Lines int
+	Words map[string]int
+}
+
+func TestKey(t *testing.T) {
+	if cachemust.Key("a", "bc") == cachemust.Key("ab", "c") {
+		t.Error("Key does not distinguish input boundaries")
+	}
+	if cachemust.Key(map[string]int{"a": 1, "b": 2}) != cachemust.Key(map[string]int{"b": 2, "a": 1}) {
+		t.Error("Key is not deterministic for maps")
+	}
+	if cachemust.Key([]byte("x")) != cachemust.Key("x") {
+		t.Error("Key differs for the same bytes")
+	}
+	if cachemust.Key(1) == cachemust.Key("1") || cachemust.Key(true) == cachemust.Key("true") {
+		t.Error("Key does not distinguish input types")
+	}
+}
+
+func TestCache(t *testing.T) {
+	t.Run("New uses the user cache directory", func(t *testing.T) {
+		if runtime.GOOS != "linux" {
+			t.Skip("XDG_CACHE_HOME is used only on Linux")
+		}
+		t.Setenv("XDG_CACHE_HOME", t.TempDir())
+		c := cachemust.New("cachemust-test")
+		if want := filepath.Join(os.Getenv("XDG_CACHE_HOME"), "cachemust-test"); c.Dir() != want {
+			t.Errorf("expected %s, got %s", want, c.Dir())
+		}
+	})
+
+	t.Run("Put and Get", func(t *testing.T) {
+		c := cachemust.NewAt(t.TempDir())
+		key := cachemust.Key("input.txt", []byte("hello world"))
+		if _, ok := cachemust.Get[result](c, key); ok {
+			t.Fatal("Get found a value in an empty cache")
+		}
+		c.Put(key, result{Lines: 1, Words: map[string]int{"hello": 1, "world": 1}})
+		got, ok := cachemust.Get[result](c, key)
+		if !ok || got.Lines != 1 || got.Words["world"] != 1 {
+			t.Errorf("unexpected value %+v, %v", got, ok)
+		}
+
+		c.Delete(key)
+		if _, ok := cachemust.Get[result](c, key); ok {
+			t.Error("Get found a deleted value")
+		}
+	})
+
+	t.Run("GetOrPut computes once", func(t *testing.T) {
+		c := cachemust.NewAt(t.TempDir())
+		calls := 0
+		compute := func() result {
+			calls++
+			return result{Lines: 3}
+		}
+		for range 3 {
+			if got := cachemust.GetOrPut(c, "k", compute); got.Lines != 3 {
+				t.Errorf("unexpected value %+v", got)
+			}
+		}
+		if calls != 1 {
+			t.Errorf("expected compute to be called once, got %d", calls)
+		}
+
+		c.Clear()
+		cachemust.GetOrPut(c, "k", compute)
+		if calls != 2 {
+			t.Errorf("expected compute to be called after Clear, got %d calls", calls)
+		}
+	})
+
+	t.Run("keys stay in the directory", func(t *testing.T) {
+		parent := t.TempDir()
+		c := cachemust.NewAt(filepath.Join(parent, "cache"))
+		c.Put("../x", 1)
+		if v, ok := cachemust.Get[int](c, "../x"); !ok || v != 1 {
+			t.Errorf("unexpected value %v, %v", v, ok)
+		}
+		entries, err := os.ReadDir(parent)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 1 {
+			t.Errorf("expected only the cache directory in %s, got %d entries", parent, len(entries))
+		}
+	})
+
+	t.Run("value of a changed type is a miss", func(t *testing.T) {
+		c := cachemust.NewAt(t.TempDir())
+		c.Put("k", "text")
+		if _, ok := cachemust.Get[result](c, "k"); ok {
+			t.Error("Get decoded a value of another type")
+		}
+		if _, ok := cachemust.Get[string](c, "k"); ok {
+			t.Error("Get did not remove the undecodable value")
+		}
+	})
+}
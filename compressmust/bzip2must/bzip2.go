@@ -0,0 +1,14 @@
+// Package bzip2must provides wrappers for the compress/bzip2 package with panicking error handling.
+// The standard library supports only decompression.
+package bzip2must
+
+import (
+	"compress/bzip2"
+
+	"github.com/Jumpaku/go-mustd/iomust"
+)
+
+// NewReader returns a new Reader decompressing r. Read panics if the input is not valid bzip2 data.
+func NewReader(r iomust.Reader) iomust.Reader {
+	return iomust.ReaderOf(bzip2.NewReader(r.Reader()))
+}
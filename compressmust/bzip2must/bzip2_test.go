@@ -0,0 +1,33 @@
+package bzip2must_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/Jumpaku/go-mustd/compressmust/bzip2must"
+	"github.com/Jumpaku/go-mustd/iomust"
+)
+
+// helloBzip2 is "hello bzip2\n" compressed by bzip2.
+const helloBzip2 = "425a6839314159265359ab6ba1f1000002d9800010400010001264c01020003100d34d04001ea3ef4e51a2078bb9229c284855b5d0f880"
+
+func TestNewReader(t *testing.T) {
+	t.Run("decompress", func(t *testing.T) {
+		data, _ := hex.DecodeString(helloBzip2)
+		r := bzip2must.NewReader(iomust.ReaderOf(bytes.NewReader(data)))
+		if got := string(iomust.ReadAll(r)); got != "hello bzip2\n" {
+			t.Errorf("expected %q, got %q", "hello bzip2\n", got)
+		}
+	})
+
+	t.Run("invalid data panics", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("Read did not panic with invalid data")
+			}
+		}()
+		iomust.ReadAll(bzip2must.NewReader(iomust.ReaderOf(strings.NewReader("not bzip2 data"))))
+	})
+}
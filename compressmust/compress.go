@@ -0,0 +1,109 @@
+// Package compressmust provides utilities for compressed data and its subpackages wrap the compress packages with panicking error handling.
+package compressmust
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"os"
+
+	"github.com/Jumpaku/go-mustd"
+	"github.com/Jumpaku/go-mustd/iomust"
+)
+
+// Format is a compression format detected by its magic bytes.
+type Format int
+
+const (
+	// None means the data is not compressed in a detectable format.
+	None Format = iota
+	// Gzip is the gzip format (RFC 1952).
+	Gzip
+	// Zlib is the zlib format (RFC 1950).
+	Zlib
+	// Bzip2 is the bzip2 format.
+	Bzip2
+)
+
+func (f Format) String() string {
+	switch f {
+	case Gzip:
+		return "gzip"
+	case Zlib:
+		return "zlib"
+	case Bzip2:
+		return "bzip2"
+	default:
+		return "none"
+	}
+}
+
+// Detect returns the compression format of data by its leading magic bytes.
+// Raw DEFLATE and LZW data have no magic bytes and are reported as None.
+// Zlib is detected only with the 32K window, which is what zlib writers use, and without a preset dictionary, so that plain text is not mistaken for it.
+func Detect(data []byte) Format {
+	switch {
+	case bytes.HasPrefix(data, []byte{0x1f, 0x8b}):
+		return Gzip
+	case len(data) >= 4 && bytes.HasPrefix(data, []byte("BZh")) && '1' <= data[3] && data[3] <= '9':
+		return Bzip2
+	case len(data) >= 2 && data[0] == 0x78 && data[1]&0x20 == 0 && (uint16(data[0])<<8|uint16(data[1]))%31 == 0:
+		return Zlib
+	}
+	return None
+}
+
+// NewAutoReader returns a ReadCloser decompressing r in the format detected by its magic bytes, or reading r as it is if no format is detected.
+// Close does not close r. Panics if an error occurs reading the header.
+func NewAutoReader(r iomust.Reader) iomust.ReadCloser {
+	return iomust.ReadCloserOf(mustd.Must1(newAutoReader(r.Reader())))
+}
+
+// OpenAuto opens the named file and returns a ReadCloser decompressing it in the format detected by its magic bytes,
+// or reading it as it is if no format is detected. Close closes the file. Panics if an error occurs.
+func OpenAuto(name string) iomust.ReadCloser {
+	f := mustd.Must1(os.Open(name))
+	rc, err := newAutoReader(f)
+	if err != nil {
+		f.Close()
+		panic(err)
+	}
+	return iomust.ReadCloserOf(&readCloser{Reader: rc, closers: []io.Closer{rc, f}})
+}
+
+func newAutoReader(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(4)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	switch Detect(magic) {
+	case Gzip:
+		return gzip.NewReader(br)
+	case Zlib:
+		return zlib.NewReader(br)
+	case Bzip2:
+		return io.NopCloser(bzip2.NewReader(br)), nil
+	default:
+		return io.NopCloser(br), nil
+	}
+}
+
+// readCloser reads from Reader and closes all closers in order on Close, returning the first error.
+type readCloser struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (r *readCloser) Close() error {
+	var firstErr error
+	for _, c := range r.closers {
+		if err := c.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,96 @@
+package compressmust_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Jumpaku/go-mustd/compressmust"
+	"github.com/Jumpaku/go-mustd/compressmust/gzipmust"
+	"github.com/Jumpaku/go-mustd/compressmust/zlibmust"
+	"github.com/Jumpaku/go-mustd/iomust"
+)
+
+// helloBzip2 is "hello bzip2\n" compressed by bzip2.
+const helloBzip2 = "425a6839314159265359ab6ba1f1000002d9800010400010001264c01020003100d34d04001ea3ef4e51a2078bb9229c284855b5d0f880"
+
+func compress(newWriter func(w iomust.Writer) iomust.WriteCloser, data string) []byte {
+	buf := &bytes.Buffer{}
+	w := newWriter(iomust.WriterOf(buf))
+	w.Write([]byte(data))
+	w.Close()
+	return buf.Bytes()
+}
+
+func TestOpenAuto(t *testing.T) {
+	bz2, _ := hex.DecodeString(helloBzip2)
+	testCases := []struct {
+		name   string
+		data   []byte
+		format compressmust.Format
+		want   string
+	}{
+		{name: "app.log.gz", data: compress(gzipmust.NewWriter, "hello gzip\n"), format: compressmust.Gzip, want: "hello gzip\n"},
+		{name: "app.log.zz", data: compress(zlibmust.NewWriter, "hello zlib\n"), format: compressmust.Zlib, want: "hello zlib\n"},
+		{name: "app.log.bz2", data: bz2, format: compressmust.Bzip2, want: "hello bzip2\n"},
+		{name: "app.log", data: []byte("hello plain\n"), format: compressmust.None, want: "hello plain\n"},
+		{name: "empty.log", data: nil, format: compressmust.None, want: ""},
+		{name: "hives.csv", data: []byte("HKLM,value\n"), format: compressmust.None, want: "HKLM,value\n"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := compressmust.Detect(tc.data); got != tc.format {
+				t.Errorf("expected format %v, got %v", tc.format, got)
+			}
+			name := filepath.Join(t.TempDir(), tc.name)
+			if err := os.WriteFile(name, tc.data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			r := compressmust.OpenAuto(name)
+			defer r.Close()
+			if got := string(iomust.ReadAll(r)); got != tc.want {
+				t.Errorf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+
+	t.Run("NewAutoReader", func(t *testing.T) {
+		r := compressmust.NewAutoReader(iomust.ReaderOf(bytes.NewReader(compress(gzipmust.NewWriter, "stream"))))
+		if got := string(iomust.ReadAll(r)); got != "stream" {
+			t.Errorf("expected stream, got %q", got)
+		}
+	})
+
+	t.Run("NewAutoReader with truncated gzip header panics", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("NewAutoReader did not panic")
+			}
+		}()
+		compressmust.NewAutoReader(iomust.ReaderOf(bytes.NewReader([]byte{0x1f, 0x8b})))
+	})
+
+	t.Run("corrupt gzip panics", func(t *testing.T) {
+		name := filepath.Join(t.TempDir(), "corrupt.gz")
+		if err := os.WriteFile(name, []byte{0x1f, 0x8b, 0x00}, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("OpenAuto did not panic")
+			}
+		}()
+		compressmust.OpenAuto(name)
+	})
+
+	t.Run("missing file panics", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("OpenAuto did not panic")
+			}
+		}()
+		compressmust.OpenAuto(filepath.Join(t.TempDir(), "missing.gz"))
+	})
+}
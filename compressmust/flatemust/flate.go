@@ -0,0 +1,30 @@
+// Package flatemust provides wrappers for the compress/flate package with panicking error handling.
+package flatemust
+
+import (
+	"compress/flate"
+
+	"github.com/Jumpaku/go-mustd"
+	"github.com/Jumpaku/go-mustd/iomust"
+)
+
+// NewReader returns a new ReadCloser decompressing r. Close does not close r.
+func NewReader(r iomust.Reader) iomust.ReadCloser {
+	return iomust.ReadCloserOf(flate.NewReader(r.Reader()))
+}
+
+// NewReaderDict is like NewReader but initializes the reader with a preset dictionary.
+func NewReaderDict(r iomust.Reader, dict []byte) iomust.ReadCloser {
+	return iomust.ReadCloserOf(flate.NewReaderDict(r.Reader(), dict))
+}
+
+// NewWriter returns a new WriteCloser compressing data written to w at the given level. Panics if the level is invalid.
+// Close must be called to flush the remaining data, and it panics if an error occurs. It does not close w.
+func NewWriter(w iomust.Writer, level int) iomust.WriteCloser {
+	return iomust.WriteCloserOf(mustd.Must1(flate.NewWriter(w.Writer(), level)))
+}
+
+// NewWriterDict is like NewWriter but initializes the writer with a preset dictionary. Panics if the level is invalid.
+func NewWriterDict(w iomust.Writer, level int, dict []byte) iomust.WriteCloser {
+	return iomust.WriteCloserOf(mustd.Must1(flate.NewWriterDict(w.Writer(), level, dict)))
+}
@@ -0,0 +1,47 @@
+package flatemust_test
+
+import (
+	"bytes"
+	"compress/flate"
+	"testing"
+
+	"github.com/Jumpaku/go-mustd/compressmust/flatemust"
+	"github.com/Jumpaku/go-mustd/iomust"
+)
+
+func TestReaderWriter(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		w := flatemust.NewWriter(iomust.WriterOf(buf), flate.BestCompression)
+		w.Write([]byte("hello flate"))
+		w.Close()
+
+		r := flatemust.NewReader(iomust.ReaderOf(buf))
+		defer r.Close()
+		if got := string(iomust.ReadAll(r)); got != "hello flate" {
+			t.Errorf("expected hello flate, got %q", got)
+		}
+	})
+
+	t.Run("round trip with dictionary", func(t *testing.T) {
+		dict := []byte("hello")
+		buf := &bytes.Buffer{}
+		w := flatemust.NewWriterDict(iomust.WriterOf(buf), flate.DefaultCompression, dict)
+		w.Write([]byte("hello hello"))
+		w.Close()
+
+		r := flatemust.NewReaderDict(iomust.ReaderOf(buf), dict)
+		if got := string(iomust.ReadAll(r)); got != "hello hello" {
+			t.Errorf("expected hello hello, got %q", got)
+		}
+	})
+
+	t.Run("NewWriter invalid level panics", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("NewWriter did not panic")
+			}
+		}()
+		flatemust.NewWriter(iomust.WriterOf(&bytes.Buffer{}), 42)
+	})
+}
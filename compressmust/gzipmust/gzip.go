@@ -0,0 +1,26 @@
+// Package gzipmust provides wrappers for the compress/gzip package with panicking error handling.
+package gzipmust
+
+import (
+	"compress/gzip"
+
+	"github.com/Jumpaku/go-mustd"
+	"github.com/Jumpaku/go-mustd/iomust"
+)
+
+// NewReader returns a new ReadCloser decompressing r. Panics if an error occurs reading the header.
+// Close does not close r, and the checksum is verified only when the reader is read until the end.
+func NewReader(r iomust.Reader) iomust.ReadCloser {
+	return iomust.ReadCloserOf(mustd.Must1(gzip.NewReader(r.Reader())))
+}
+
+// NewWriter returns a new WriteCloser compressing data written to w.
+// Close must be called to write the footer, and it panics if an error occurs. It does not close w.
+func NewWriter(w iomust.Writer) iomust.WriteCloser {
+	return iomust.WriteCloserOf(gzip.NewWriter(w.Writer()))
+}
+
+// NewWriterLevel is like NewWriter but specifies the compression level. Panics if the level is invalid.
+func NewWriterLevel(w iomust.Writer, level int) iomust.WriteCloser {
+	return iomust.WriteCloserOf(mustd.Must1(gzip.NewWriterLevel(w.Writer(), level)))
+}
@@ -0,0 +1,71 @@
+package gzipmust_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Jumpaku/go-mustd/compressmust/gzipmust"
+	"github.com/Jumpaku/go-mustd/iomust"
+)
+
+// limitedWriter fails when more than limit bytes are written.
+type limitedWriter struct {
+	limit int
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	if len(p) > w.limit {
+		return 0, errors.New("disk full")
+	}
+	w.limit -= len(p)
+	return len(p), nil
+}
+
+func TestReaderWriter(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		w := gzipmust.NewWriterLevel(iomust.WriterOf(buf), gzip.BestCompression)
+		w.Write([]byte(strings.Repeat("log line\n", 100)))
+		w.Close()
+
+		r := gzipmust.NewReader(iomust.ReaderOf(buf))
+		defer r.Close()
+		if got := string(iomust.ReadAll(r)); got != strings.Repeat("log line\n", 100) {
+			t.Errorf("unexpected decompressed data %q", got)
+		}
+	})
+
+	t.Run("NewReader invalid header panics", func(t *testing.T) {
+		defer func() {
+			err, _ := recover().(error)
+			if !errors.Is(err, gzip.ErrHeader) {
+				t.Errorf("expected gzip.ErrHeader, got %v", err)
+			}
+		}()
+		gzipmust.NewReader(iomust.ReaderOf(strings.NewReader("not gzip data")))
+	})
+
+	t.Run("Close panics on write error", func(t *testing.T) {
+		// The header fits in the limit, and the compressed data and the footer are written on Close.
+		w := gzipmust.NewWriter(iomust.WriterOf(&limitedWriter{limit: 10}))
+		w.Write([]byte("data"))
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("Close did not panic")
+			}
+		}()
+		w.Close()
+	})
+
+	t.Run("NewWriterLevel invalid level panics", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("NewWriterLevel did not panic")
+			}
+		}()
+		gzipmust.NewWriterLevel(iomust.WriterOf(&bytes.Buffer{}), 42)
+	})
+}
@@ -0,0 +1,19 @@
+// Package lzwmust provides wrappers for the compress/lzw package with panicking error handling.
+package lzwmust
+
+import (
+	"compress/lzw"
+
+	"github.com/Jumpaku/go-mustd/iomust"
+)
+
+// NewReader returns a new ReadCloser decompressing r, which is in the given bit order and literal code width. Close does not close r.
+func NewReader(r iomust.Reader, order lzw.Order, litWidth int) iomust.ReadCloser {
+	return iomust.ReadCloserOf(lzw.NewReader(r.Reader(), order, litWidth))
+}
+
+// NewWriter returns a new WriteCloser compressing data written to w in the given bit order and literal code width.
+// Close must be called to flush the remaining data, and it panics if an error occurs. It does not close w.
+func NewWriter(w iomust.Writer, order lzw.Order, litWidth int) iomust.WriteCloser {
+	return iomust.WriteCloserOf(lzw.NewWriter(w.Writer(), order, litWidth))
+}
@@ -0,0 +1,35 @@
+package lzwmust_test
+
+import (
+	"bytes"
+	"compress/lzw"
+	"testing"
+
+	"github.com/Jumpaku/go-mustd/compressmust/lzwmust"
+	"github.com/Jumpaku/go-mustd/iomust"
+)
+
+func TestReaderWriter(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		w := lzwmust.NewWriter(iomust.WriterOf(buf), lzw.LSB, 8)
+		w.Write([]byte("TOBEORNOTTOBEORTOBEORNOT"))
+		w.Close()
+
+		r := lzwmust.NewReader(iomust.ReaderOf(buf), lzw.LSB, 8)
+		defer r.Close()
+		if got := string(iomust.ReadAll(r)); got != "TOBEORNOTTOBEORTOBEORNOT" {
+			t.Errorf("unexpected decompressed data %q", got)
+		}
+	})
+
+	t.Run("Write invalid literal panics", func(t *testing.T) {
+		w := lzwmust.NewWriter(iomust.WriterOf(&bytes.Buffer{}), lzw.MSB, 2)
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("Write did not panic")
+			}
+		}()
+		w.Write([]byte{0xff})
+	})
+}
@@ -0,0 +1,36 @@
+// Package zlibmust provides wrappers for the compress/zlib package with panicking error handling.
+package zlibmust
+
+import (
+	"compress/zlib"
+
+	"github.com/Jumpaku/go-mustd"
+	"github.com/Jumpaku/go-mustd/iomust"
+)
+
+// NewReader returns a new ReadCloser decompressing r. Panics if an error occurs reading the header.
+// Close does not close r.
+func NewReader(r iomust.Reader) iomust.ReadCloser {
+	return iomust.ReadCloserOf(mustd.Must1(zlib.NewReader(r.Reader())))
+}
+
+// NewReaderDict is like NewReader but uses a preset dictionary. Panics if an error occurs reading the header.
+func NewReaderDict(r iomust.Reader, dict []byte) iomust.ReadCloser {
+	return iomust.ReadCloserOf(mustd.Must1(zlib.NewReaderDict(r.Reader(), dict)))
+}
+
+// NewWriter returns a new WriteCloser compressing data written to w.
+// Close must be called to write the checksum, and it panics if an error occurs. It does not close w.
+func NewWriter(w iomust.Writer) iomust.WriteCloser {
+	return iomust.WriteCloserOf(zlib.NewWriter(w.Writer()))
+}
+
+// NewWriterLevel is like NewWriter but specifies the compression level. Panics if the level is invalid.
+func NewWriterLevel(w iomust.Writer, level int) iomust.WriteCloser {
+	return iomust.WriteCloserOf(mustd.Must1(zlib.NewWriterLevel(w.Writer(), level)))
+}
+
+// NewWriterLevelDict is like NewWriterLevel but uses a preset dictionary. Panics if the level is invalid.
+func NewWriterLevelDict(w iomust.Writer, level int, dict []byte) iomust.WriteCloser {
+	return iomust.WriteCloserOf(mustd.Must1(zlib.NewWriterLevelDict(w.Writer(), level, dict)))
+}
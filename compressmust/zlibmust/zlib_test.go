@@ -0,0 +1,64 @@
+package zlibmust_test
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Jumpaku/go-mustd/compressmust/zlibmust"
+	"github.com/Jumpaku/go-mustd/iomust"
+)
+
+func TestReaderWriter(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		w := zlibmust.NewWriter(iomust.WriterOf(buf))
+		w.Write([]byte("hello zlib"))
+		w.Close()
+
+		r := zlibmust.NewReader(iomust.ReaderOf(buf))
+		defer r.Close()
+		if got := string(iomust.ReadAll(r)); got != "hello zlib" {
+			t.Errorf("expected hello zlib, got %q", got)
+		}
+	})
+
+	t.Run("round trip with dictionary", func(t *testing.T) {
+		dict := []byte("hello")
+		buf := &bytes.Buffer{}
+		w := zlibmust.NewWriterLevelDict(iomust.WriterOf(buf), zlib.BestSpeed, dict)
+		w.Write([]byte("hello hello"))
+		w.Close()
+
+		r := zlibmust.NewReaderDict(iomust.ReaderOf(buf), dict)
+		if got := string(iomust.ReadAll(r)); got != "hello hello" {
+			t.Errorf("expected hello hello, got %q", got)
+		}
+	})
+
+	t.Run("NewReader invalid header panics", func(t *testing.T) {
+		defer func() {
+			err, _ := recover().(error)
+			if !errors.Is(err, zlib.ErrHeader) {
+				t.Errorf("expected zlib.ErrHeader, got %v", err)
+			}
+		}()
+		zlibmust.NewReader(iomust.ReaderOf(strings.NewReader("not zlib data")))
+	})
+
+	t.Run("truncated data panics", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		w := zlibmust.NewWriterLevel(iomust.WriterOf(buf), zlib.DefaultCompression)
+		w.Write([]byte(strings.Repeat("data", 100)))
+		w.Close()
+		r := zlibmust.NewReader(iomust.ReaderOf(bytes.NewReader(buf.Bytes()[:buf.Len()-4])))
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("reading truncated data did not panic")
+			}
+		}()
+		iomust.ReadAll(r)
+	})
+}
@@ -0,0 +1,251 @@
+// Package certmust provides throwaway certificate authorities and certificates for TLS in local testing with panicking error handling.
+// Certificates are generated offline, and deterministically with a clock and a source of randomness supplied by WithClock and WithRand.
+package certmust
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"time"
+
+	"github.com/Jumpaku/go-mustd"
+	"github.com/Jumpaku/go-mustd/cryptomust/pemmust"
+	"github.com/Jumpaku/go-mustd/encodingmust"
+)
+
+// KeyAlgorithm is an algorithm of the keys of certificates.
+type KeyAlgorithm int
+
+const (
+	// Ed25519 generates Ed25519 keys, whose certificates are entirely determined by the clock and the source of randomness.
+	Ed25519 KeyAlgorithm = iota
+	// ECDSAP256 generates ECDSA keys on the P-256 curve, which are supported by more TLS implementations.
+	// The keys are determined by the source of randomness but the signatures of the certificates are randomized.
+	ECDSAP256
+)
+
+// Option configures a CA created by NewCA and the certificates issued by it.
+type Option func(*options)
+
+type options struct {
+	commonName   string
+	validity     time.Duration
+	now          func() time.Time
+	rand         io.Reader
+	keyAlgorithm KeyAlgorithm
+}
+
+// WithCommonName returns an Option that sets the common name of the CA. The default is "go-mustd test CA".
+func WithCommonName(name string) Option {
+	return func(o *options) {
+		o.commonName = name
+	}
+}
+
+// WithValidity returns an Option that sets the period for which the certificates are valid from their issuance. The default is 24 hours.
+func WithValidity(d time.Duration) Option {
+	return func(o *options) {
+		o.validity = d
+	}
+}
+
+// WithClock returns an Option that uses now as the current time to issue certificates and to verify them in the TLS configurations.
+// The default is time.Now.
+func WithClock(now func() time.Time) Option {
+	return func(o *options) {
+		o.now = now
+	}
+}
+
+// WithRand returns an Option that reads the keys and the serial numbers from r. The default is crypto/rand.Reader.
+// A deterministic r makes the certificates reproducible, which must be used only for testing.
+func WithRand(r io.Reader) Option {
+	return func(o *options) {
+		o.rand = r
+	}
+}
+
+// WithKeyAlgorithm returns an Option that generates keys by a. The default is Ed25519.
+func WithKeyAlgorithm(a KeyAlgorithm) Option {
+	return func(o *options) {
+		o.keyAlgorithm = a
+	}
+}
+
+// generateKey returns a new private key of the algorithm read from the source of randomness.
+func (o options) generateKey() crypto.Signer {
+	switch o.keyAlgorithm {
+	case Ed25519:
+		seed := make([]byte, ed25519.SeedSize)
+		mustd.Must1(io.ReadFull(o.rand, seed))
+		return ed25519.NewKeyFromSeed(seed)
+	case ECDSAP256:
+		for {
+			d := make([]byte, 32)
+			mustd.Must1(io.ReadFull(o.rand, d))
+			// Retry in the unlikely case that d is not less than the order of the curve.
+			if key, err := ecdsa.ParseRawPrivateKey(elliptic.P256(), d); err == nil {
+				return key
+			}
+		}
+	default:
+		panic(fmt.Errorf("certmust: unknown key algorithm %d", o.keyAlgorithm))
+	}
+}
+
+// serialNumber returns a random 128-bit serial number read from the source of randomness.
+func (o options) serialNumber() *big.Int {
+	b := make([]byte, 16)
+	mustd.Must1(io.ReadFull(o.rand, b))
+	return new(big.Int).SetBytes(b)
+}
+
+// CA is a certificate authority issuing certificates.
+type CA struct {
+	// Certificate is the self-signed certificate of the CA.
+	Certificate *x509.Certificate
+	// Key is the private key of the CA.
+	Key     crypto.Signer
+	options options
+}
+
+// NewCA returns a new CA with a self-signed certificate. Panics if an error occurs.
+func NewCA(opts ...Option) *CA {
+	o := options{commonName: "go-mustd test CA", validity: 24 * time.Hour, now: time.Now, rand: rand.Reader}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	key := o.generateKey()
+	now := o.now()
+	template := &x509.Certificate{
+		SerialNumber:          o.serialNumber(),
+		Subject:               pkix.Name{CommonName: o.commonName},
+		NotBefore:             now,
+		NotAfter:              now.Add(o.validity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+	der := mustd.Must1(x509.CreateCertificate(o.rand, template, template, key.Public(), key))
+	return &CA{Certificate: mustd.Must1(x509.ParseCertificate(der)), Key: key, options: o}
+}
+
+// Issue returns a new certificate signed by the CA for hosts, which are DNS names or IP addresses, for both server and client authentication.
+// If no host is given, the certificate is issued for localhost, 127.0.0.1 and ::1. Panics if an error occurs.
+func (ca *CA) Issue(hosts ...string) *Pair {
+	if len(hosts) == 0 {
+		hosts = []string{"localhost", "127.0.0.1", "::1"}
+	}
+	key := ca.options.generateKey()
+	now := ca.options.now()
+	template := &x509.Certificate{
+		SerialNumber: ca.options.serialNumber(),
+		Subject:      pkix.Name{CommonName: hosts[0]},
+		NotBefore:    now,
+		NotAfter:     now.Add(ca.options.validity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+	der := mustd.Must1(x509.CreateCertificate(ca.options.rand, template, ca.Certificate, key.Public(), ca.Key))
+	return &Pair{Certificate: mustd.Must1(x509.ParseCertificate(der)), Key: key, CA: ca}
+}
+
+// CertPEM returns the PEM encoding of the certificate of the CA.
+func (ca *CA) CertPEM() []byte {
+	return pemmust.MarshalCertificate(ca.Certificate)
+}
+
+// KeyPEM returns the PEM encoding of the private key of the CA in PKCS #8. Panics if an error occurs.
+func (ca *CA) KeyPEM() []byte {
+	return pemmust.MarshalPrivateKey(ca.Key)
+}
+
+// WriteFiles writes the PEM encodings of the certificate and the private key of the CA to the named files. Panics if an error occurs.
+// The certificate file is readable by all users and the key file only by the owner.
+func (ca *CA) WriteFiles(certFile, keyFile string) {
+	writeFiles(certFile, ca.CertPEM(), keyFile, ca.KeyPEM())
+}
+
+// CertPool returns a new x509.CertPool containing the certificate of the CA.
+func (ca *CA) CertPool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.Certificate)
+	return pool
+}
+
+// ClientConfig returns a new tls.Config for clients trusting the CA at the time given by the clock.
+func (ca *CA) ClientConfig() *tls.Config {
+	return &tls.Config{RootCAs: ca.CertPool(), Time: ca.options.now, MinVersion: tls.VersionTLS12}
+}
+
+// Pair is a certificate issued by a CA and its private key.
+type Pair struct {
+	// Certificate is the certificate signed by CA.
+	Certificate *x509.Certificate
+	// Key is the private key of the certificate.
+	Key crypto.Signer
+	// CA is the CA which issued the certificate.
+	CA *CA
+}
+
+// CertPEM returns the PEM encoding of the certificate.
+func (p *Pair) CertPEM() []byte {
+	return pemmust.MarshalCertificate(p.Certificate)
+}
+
+// KeyPEM returns the PEM encoding of the private key in PKCS #8. Panics if an error occurs.
+func (p *Pair) KeyPEM() []byte {
+	return pemmust.MarshalPrivateKey(p.Key)
+}
+
+// WriteFiles writes the PEM encodings of the certificate and the private key to the named files. Panics if an error occurs.
+// The certificate file is readable by all users and the key file only by the owner.
+func (p *Pair) WriteFiles(certFile, keyFile string) {
+	writeFiles(certFile, p.CertPEM(), keyFile, p.KeyPEM())
+}
+
+// TLSCertificate returns the certificate and the private key as a tls.Certificate.
+func (p *Pair) TLSCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{p.Certificate.Raw}, PrivateKey: p.Key, Leaf: p.Certificate}
+}
+
+// ServerConfig returns a new tls.Config for servers presenting the certificate.
+// Client certificates issued by the CA are verified at the time given by the clock if ClientAuth of the result is set to require them.
+func (p *Pair) ServerConfig() *tls.Config {
+	return &tls.Config{
+		Certificates: []tls.Certificate{p.TLSCertificate()},
+		ClientCAs:    p.CA.CertPool(),
+		Time:         p.CA.options.now,
+		MinVersion:   tls.VersionTLS12,
+	}
+}
+
+// ClientConfig returns a new tls.Config for clients trusting the CA and presenting the certificate for mutual TLS.
+func (p *Pair) ClientConfig() *tls.Config {
+	config := p.CA.ClientConfig()
+	config.Certificates = []tls.Certificate{p.TLSCertificate()}
+	return config
+}
+
+// writeFiles writes the PEM-encoded certificate and private key to the named files.
+func writeFiles(certFile string, certPEM []byte, keyFile string, keyPEM []byte) {
+	encodingmust.WriteFileAtomic(certFile, certPEM, 0o644)
+	encodingmust.WriteFileAtomic(keyFile, keyPEM, 0o600)
+}
@@ -0,0 +1,156 @@
+package certmust_test
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/tls"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Jumpaku/go-mustd/cryptomust/certmust"
+	"github.com/Jumpaku/go-mustd/cryptomust/pemmust"
+)
+
+// past is a fixed time at which the certificates of the tests are issued, which shows that verification uses the supplied clock.
+var past = time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)
+
+func clock() time.Time {
+	return past
+}
+
+func seeded(seed byte) io.Reader {
+	return rand.NewChaCha8([32]byte{seed})
+}
+
+// get sends a request to server with the TLS configuration of a client and returns the response body.
+func get(t *testing.T, server *httptest.Server, config *tls.Config) string {
+	t.Helper()
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: config}}
+	res, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+func TestCA(t *testing.T) {
+	t.Run("deterministic", func(t *testing.T) {
+		issue := func() ([]byte, []byte) {
+			ca := certmust.NewCA(certmust.WithClock(clock), certmust.WithRand(seeded(1)))
+			pair := ca.Issue("example.test")
+			return ca.CertPEM(), append(pair.CertPEM(), pair.KeyPEM()...)
+		}
+		caPEM1, pairPEM1 := issue()
+		caPEM2, pairPEM2 := issue()
+		if !bytes.Equal(caPEM1, caPEM2) || !bytes.Equal(pairPEM1, pairPEM2) {
+			t.Error("certificates differ with the same clock and rand")
+		}
+		other := certmust.NewCA(certmust.WithClock(clock), certmust.WithRand(seeded(2)))
+		if bytes.Equal(caPEM1, other.CertPEM()) {
+			t.Error("certificates are equal with different rand")
+		}
+	})
+
+	t.Run("certificate contents", func(t *testing.T) {
+		ca := certmust.NewCA(certmust.WithClock(clock), certmust.WithCommonName("my CA"), certmust.WithValidity(time.Hour))
+		pair := ca.Issue("example.test", "192.0.2.1")
+		if !ca.Certificate.IsCA || ca.Certificate.Subject.CommonName != "my CA" {
+			t.Errorf("unexpected CA certificate %v", ca.Certificate.Subject)
+		}
+		cert := pair.Certificate
+		if !cert.NotBefore.Equal(past) || !cert.NotAfter.Equal(past.Add(time.Hour)) {
+			t.Errorf("unexpected validity %v - %v", cert.NotBefore, cert.NotAfter)
+		}
+		if len(cert.DNSNames) != 1 || cert.DNSNames[0] != "example.test" || len(cert.IPAddresses) != 1 || cert.IPAddresses[0].String() != "192.0.2.1" {
+			t.Errorf("unexpected names %v %v", cert.DNSNames, cert.IPAddresses)
+		}
+		if err := cert.CheckSignatureFrom(ca.Certificate); err != nil {
+			t.Errorf("certificate is not signed by the CA: %v", err)
+		}
+	})
+
+	t.Run("ECDSA", func(t *testing.T) {
+		ca := certmust.NewCA(certmust.WithKeyAlgorithm(certmust.ECDSAP256), certmust.WithRand(seeded(3)))
+		pair := ca.Issue()
+		if _, ok := pair.Key.(*ecdsa.PrivateKey); !ok {
+			t.Errorf("unexpected key type %T", pair.Key)
+		}
+		again := certmust.NewCA(certmust.WithKeyAlgorithm(certmust.ECDSAP256), certmust.WithRand(seeded(3)))
+		if !ca.Key.(*ecdsa.PrivateKey).Equal(again.Key) {
+			t.Error("keys differ with the same rand")
+		}
+	})
+
+	t.Run("WriteFiles", func(t *testing.T) {
+		dir := t.TempDir()
+		ca := certmust.NewCA()
+		pair := ca.Issue()
+		ca.WriteFiles(filepath.Join(dir, "ca.pem"), filepath.Join(dir, "ca-key.pem"))
+		pair.WriteFiles(filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"))
+
+		if _, err := tls.LoadX509KeyPair(filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")); err != nil {
+			t.Errorf("written files are not a key pair: %v", err)
+		}
+		if certs := pemmust.ReadCertificateFile(filepath.Join(dir, "ca.pem")); !certs[0].Equal(ca.Certificate) {
+			t.Error("written CA certificate differs")
+		}
+		info, err := os.Stat(filepath.Join(dir, "key.pem"))
+		if err != nil || info.Mode().Perm() != 0o600 {
+			t.Errorf("unexpected key file info %v, %v", info, err)
+		}
+	})
+}
+
+func TestTLSConfig(t *testing.T) {
+	ca := certmust.NewCA(certmust.WithClock(clock))
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := "anonymous"
+		if len(r.TLS.PeerCertificates) > 0 {
+			name = r.TLS.PeerCertificates[0].Subject.CommonName
+		}
+		io.WriteString(w, "hello "+name)
+	})
+
+	t.Run("server authentication", func(t *testing.T) {
+		server := httptest.NewUnstartedServer(handler)
+		server.TLS = ca.Issue().ServerConfig()
+		server.StartTLS()
+		defer server.Close()
+
+		if got := get(t, server, ca.ClientConfig()); got != "hello anonymous" {
+			t.Errorf("unexpected response %q", got)
+		}
+		other := certmust.NewCA(certmust.WithClock(clock))
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: other.ClientConfig()}}
+		if _, err := client.Get(server.URL); err == nil {
+			t.Error("certificate of another CA was accepted")
+		}
+	})
+
+	t.Run("mutual TLS", func(t *testing.T) {
+		server := httptest.NewUnstartedServer(handler)
+		server.TLS = ca.Issue().ServerConfig()
+		server.TLS.ClientAuth = tls.RequireAndVerifyClientCert
+		server.StartTLS()
+		defer server.Close()
+
+		if got := get(t, server, ca.Issue("client").ClientConfig()); got != "hello client" {
+			t.Errorf("unexpected response %q", got)
+		}
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: ca.ClientConfig()}}
+		if _, err := client.Get(server.URL); err == nil {
+			t.Error("client without a certificate was accepted")
+		}
+	})
+}
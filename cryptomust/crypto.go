@@ -0,0 +1,150 @@
+// Package cryptomust provides random values, message authentication and authenticated encryption with panicking error handling.
+package cryptomust
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"math/big"
+
+	"github.com/Jumpaku/go-mustd"
+	"github.com/Jumpaku/go-mustd/hashmust"
+)
+
+const (
+	// Alphanumeric is the alphabet of ASCII letters and digits.
+	Alphanumeric = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+	// LowerAlphanumeric is the alphabet of lowercase ASCII letters and digits.
+	LowerAlphanumeric = "abcdefghijklmnopqrstuvwxyz0123456789"
+	// Digits is the alphabet of decimal digits.
+	Digits = "0123456789"
+	// HexDigits is the alphabet of lowercase hexadecimal digits.
+	HexDigits = "0123456789abcdef"
+)
+
+// RandomBytes returns n cryptographically secure random bytes. Panics if an error occurs.
+func RandomBytes(n int) []byte {
+	b := make([]byte, n)
+	mustd.Must1(rand.Read(b))
+	return b
+}
+
+// RandomString returns a cryptographically secure random string of n characters chosen uniformly from alphabet.
+// Panics if alphabet is empty or an error occurs.
+func RandomString(n int, alphabet string) string {
+	chars := []rune(alphabet)
+	if len(chars) == 0 {
+		panic(fmt.Errorf("cryptomust: empty alphabet"))
+	}
+	size := big.NewInt(int64(len(chars)))
+	s := make([]rune, n)
+	for i := range s {
+		s[i] = chars[mustd.Must1(rand.Int(rand.Reader, size)).Int64()]
+	}
+	return string(s)
+}
+
+// newHash returns the constructor of the cryptographic hash of algo. Panics if algo is not a cryptographic hash.
+func newHash(algo hashmust.Algorithm) func() hash.Hash {
+	switch algo {
+	case hashmust.SHA256, hashmust.SHA512, hashmust.SHA1, hashmust.MD5:
+		return algo.New
+	default:
+		panic(fmt.Errorf("cryptomust: %v is not a cryptographic hash", algo))
+	}
+}
+
+// HMAC returns the HMAC of data with key using the hash algo. Panics if algo is not a cryptographic hash.
+func HMAC(algo hashmust.Algorithm, key, data []byte) []byte {
+	mac := hmac.New(newHash(algo), key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// VerifyHMAC reports whether mac is the HMAC of data with key using the hash algo, comparing in constant time.
+// Panics if algo is not a cryptographic hash.
+func VerifyHMAC(algo hashmust.Algorithm, key, data, mac []byte) bool {
+	return hmac.Equal(mac, HMAC(algo, key, data))
+}
+
+// KDF derives a key of keyLength bytes from secret and salt. It panics if an error occurs.
+type KDF func(secret, salt []byte, keyLength int) []byte
+
+// HKDF returns a KDF using HKDF with SHA-256 (RFC 5869) and info as the context.
+// It is suitable for secrets with high entropy such as random keys, but not for passphrases.
+func HKDF(info string) KDF {
+	return func(secret, salt []byte, keyLength int) []byte {
+		return mustd.Must1(hkdf.Key(sha256.New, secret, salt, info, keyLength))
+	}
+}
+
+// PBKDF2 returns a KDF using PBKDF2 with HMAC-SHA-256 (RFC 8018) and the number of iterations, which is suitable for passphrases.
+// A memory-hard KDF using scrypt is provided by the separate module github.com/Jumpaku/go-mustd/cryptomust/scryptmust.
+func PBKDF2(iterations int) KDF {
+	return func(secret, salt []byte, keyLength int) []byte {
+		return mustd.Must1(pbkdf2.Key(sha256.New, string(secret), salt, iterations, keyLength))
+	}
+}
+
+const (
+	// DefaultPBKDF2Iterations is the number of iterations of PBKDF2 recommended by OWASP for HMAC-SHA-256.
+	DefaultPBKDF2Iterations = 600_000
+	// saltSize is the size of the random salt prepended to a sealed message.
+	saltSize = 16
+	// keySize is the size of the AES-256 key derived by a KDF.
+	keySize = 32
+)
+
+// AuthError is reported when a sealed message cannot be opened because it is malformed or tampered with, or the secret is wrong.
+type AuthError struct {
+	Err error
+}
+
+func (e *AuthError) Error() string {
+	return fmt.Sprintf("cryptomust: message authentication failed: %v", e.Err)
+}
+
+func (e *AuthError) Unwrap() error {
+	return e.Err
+}
+
+// newAEAD returns AES-256-GCM with the key derived from secret and salt by kdf.
+func newAEAD(kdf KDF, secret, salt []byte) cipher.AEAD {
+	block := mustd.Must1(aes.NewCipher(kdf(secret, salt, keySize)))
+	return mustd.Must1(cipher.NewGCM(block))
+}
+
+// Seal encrypts and authenticates plaintext with AES-256-GCM using a key derived from secret by kdf with a random salt.
+// The result consists of the salt, the random nonce and the ciphertext with the authentication tag, and is opened by Open with the same kdf and secret.
+// Panics if an error occurs.
+func Seal(kdf KDF, secret, plaintext []byte) []byte {
+	salt := RandomBytes(saltSize)
+	aead := newAEAD(kdf, secret, salt)
+	sealed := append(salt, RandomBytes(aead.NonceSize())...)
+	return aead.Seal(sealed, sealed[saltSize:], plaintext, nil)
+}
+
+// Open decrypts and authenticates sealed, which is the result of Seal, using a key derived from secret by kdf.
+// Panics with an *AuthError if sealed is malformed or tampered with, or the kdf or the secret differ from those used by Seal.
+func Open(kdf KDF, secret, sealed []byte) []byte {
+	if len(sealed) < saltSize {
+		panic(&AuthError{Err: fmt.Errorf("message too short")})
+	}
+	salt, rest := sealed[:saltSize], sealed[saltSize:]
+	aead := newAEAD(kdf, secret, salt)
+	if len(rest) < aead.NonceSize()+aead.Overhead() {
+		panic(&AuthError{Err: fmt.Errorf("message too short")})
+	}
+	nonce, ciphertext := rest[:aead.NonceSize()], rest[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		panic(&AuthError{Err: err})
+	}
+	return plaintext
+}
@@ -0,0 +1,110 @@
+package cryptomust_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Jumpaku/go-mustd/cryptomust"
+	"github.com/Jumpaku/go-mustd/hashmust"
+)
+
+func TestRandom(t *testing.T) {
+	t.Run("RandomBytes", func(t *testing.T) {
+		a, b := cryptomust.RandomBytes(32), cryptomust.RandomBytes(32)
+		if len(a) != 32 || bytes.Equal(a, b) {
+			t.Errorf("unexpected random bytes %x and %x", a, b)
+		}
+	})
+
+	t.Run("RandomString", func(t *testing.T) {
+		s := cryptomust.RandomString(1000, "aβ")
+		if len([]rune(s)) != 1000 || strings.Trim(s, "aβ") != "" {
+			t.Errorf("unexpected characters in %q", s)
+		}
+		if !strings.Contains(s, "a") || !strings.Contains(s, "β") {
+			t.Errorf("not all characters are chosen in %q", s)
+		}
+	})
+
+	t.Run("RandomString panics on empty alphabet", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("RandomString did not panic")
+			}
+		}()
+		cryptomust.RandomString(1, "")
+	})
+}
+
+func TestHMAC(t *testing.T) {
+	// RFC 4231 test case 2.
+	key, data := []byte("Jefe"), []byte("what do ya want for nothing?")
+	expected := "5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843"
+	mac := cryptomust.HMAC(hashmust.SHA256, key, data)
+	if hex.EncodeToString(mac) != expected {
+		t.Errorf("expected %s, got %x", expected, mac)
+	}
+	if !cryptomust.VerifyHMAC(hashmust.SHA256, key, data, mac) {
+		t.Error("VerifyHMAC rejected a valid MAC")
+	}
+	if cryptomust.VerifyHMAC(hashmust.SHA256, key, []byte("tampered"), mac) {
+		t.Error("VerifyHMAC accepted an invalid MAC")
+	}
+
+	t.Run("non-cryptographic hash panics", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("HMAC did not panic")
+			}
+		}()
+		cryptomust.HMAC(hashmust.CRC32, key, data)
+	})
+}
+
+func TestSealOpen(t *testing.T) {
+	kdfs := map[string]cryptomust.KDF{
+		"HKDF":   cryptomust.HKDF("test"),
+		"PBKDF2": cryptomust.PBKDF2(1000),
+	}
+	for name, kdf := range kdfs {
+		t.Run(name, func(t *testing.T) {
+			secret := []byte("correct horse battery staple")
+			sealed := cryptomust.Seal(kdf, secret, []byte("attack at dawn"))
+			if bytes.Contains(sealed, []byte("attack")) {
+				t.Error("plaintext is not encrypted")
+			}
+			if got := string(cryptomust.Open(kdf, secret, sealed)); got != "attack at dawn" {
+				t.Errorf("unexpected plaintext %q", got)
+			}
+			if bytes.Equal(sealed, cryptomust.Seal(kdf, secret, []byte("attack at dawn"))) {
+				t.Error("sealed messages are not randomized")
+			}
+		})
+	}
+
+	kdf := cryptomust.HKDF("test")
+	secret := cryptomust.RandomBytes(32)
+	sealed := cryptomust.Seal(kdf, secret, []byte("message"))
+	tampered := bytes.Clone(sealed)
+	tampered[len(tampered)-1] ^= 1
+	failures := map[string]func(){
+		"wrong secret": func() { cryptomust.Open(kdf, cryptomust.RandomBytes(32), sealed) },
+		"wrong KDF":    func() { cryptomust.Open(cryptomust.HKDF("other"), secret, sealed) },
+		"tampered":     func() { cryptomust.Open(kdf, secret, tampered) },
+		"too short":    func() { cryptomust.Open(kdf, secret, sealed[:20]) },
+	}
+	for name, open := range failures {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				var authErr *cryptomust.AuthError
+				if err, _ := recover().(error); !errors.As(err, &authErr) {
+					t.Errorf("expected *cryptomust.AuthError, got %v", err)
+				}
+			}()
+			open()
+		})
+	}
+}
@@ -0,0 +1,158 @@
+// Package pemmust provides wrappers for the encoding/pem package and the PEM encodings of keys and certificates of the crypto/x509 package
+// with panicking error handling.
+package pemmust
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+
+	"github.com/Jumpaku/go-mustd"
+	"github.com/Jumpaku/go-mustd/encodingmust"
+	"github.com/Jumpaku/go-mustd/iomust"
+)
+
+// Block types of the PEM encodings of certificates and keys.
+const (
+	CertificateType   = "CERTIFICATE"
+	PrivateKeyType    = "PRIVATE KEY"
+	PublicKeyType     = "PUBLIC KEY"
+	RSAPrivateKeyType = "RSA PRIVATE KEY"
+	ECPrivateKeyType  = "EC PRIVATE KEY"
+)
+
+// Decode returns the first PEM block in data and the rest of data. Panics if no PEM block is found.
+func Decode(data []byte) (*pem.Block, []byte) {
+	block, rest := pem.Decode(data)
+	if block == nil {
+		panic(fmt.Errorf("pemmust: no PEM block found"))
+	}
+	return block, rest
+}
+
+// DecodeAll returns all PEM blocks in data. Panics if no PEM block is found.
+func DecodeAll(data []byte) []*pem.Block {
+	var blocks []*pem.Block
+	for {
+		block, rest := pem.Decode(data)
+		if block == nil {
+			break
+		}
+		blocks, data = append(blocks, block), rest
+	}
+	if len(blocks) == 0 {
+		panic(fmt.Errorf("pemmust: no PEM block found"))
+	}
+	return blocks
+}
+
+// Encode writes the PEM encoding of b to w. Panics if an error occurs.
+func Encode(w iomust.Writer, b *pem.Block) {
+	mustd.Must0(pem.Encode(w.Writer(), b))
+}
+
+// EncodeToMemory returns the PEM encoding of b. Panics if b has invalid headers.
+func EncodeToMemory(b *pem.Block) []byte {
+	data := pem.EncodeToMemory(b)
+	if data == nil {
+		panic(fmt.Errorf("pemmust: invalid headers of %s block", b.Type))
+	}
+	return data
+}
+
+// ReadFile returns all PEM blocks in the named file. Panics if an error occurs or no PEM block is found.
+func ReadFile(name string) []*pem.Block {
+	return DecodeAll(mustd.Must1(os.ReadFile(name)))
+}
+
+// WriteFile writes the PEM encodings of blocks to the named file, creating it if necessary. Panics if an error occurs.
+// The file is replaced atomically by writing a temporary file in the same directory and renaming it.
+func WriteFile(name string, perm os.FileMode, blocks ...*pem.Block) {
+	var data []byte
+	for _, b := range blocks {
+		data = append(data, EncodeToMemory(b)...)
+	}
+	encodingmust.WriteFileAtomic(name, data, perm)
+}
+
+// ParseCertificates returns the certificates in the CERTIFICATE blocks in data, ignoring the other blocks.
+// Panics if an error occurs or no certificate is found.
+func ParseCertificates(data []byte) []*x509.Certificate {
+	var certs []*x509.Certificate
+	for _, b := range DecodeAll(data) {
+		if b.Type == CertificateType {
+			certs = append(certs, mustd.Must1(x509.ParseCertificate(b.Bytes)))
+		}
+	}
+	if len(certs) == 0 {
+		panic(fmt.Errorf("pemmust: no %s block found", CertificateType))
+	}
+	return certs
+}
+
+// ParseCertificate returns the certificate in the first CERTIFICATE block in data. Panics if an error occurs or no certificate is found.
+func ParseCertificate(data []byte) *x509.Certificate {
+	return ParseCertificates(data)[0]
+}
+
+// ParsePrivateKey returns the private key in the first PRIVATE KEY, RSA PRIVATE KEY or EC PRIVATE KEY block in data,
+// which is encoded in PKCS #8, PKCS #1 or SEC 1 respectively. Panics if an error occurs or no private key is found.
+func ParsePrivateKey(data []byte) crypto.Signer {
+	for _, b := range DecodeAll(data) {
+		var key any
+		switch b.Type {
+		case PrivateKeyType:
+			key = mustd.Must1(x509.ParsePKCS8PrivateKey(b.Bytes))
+		case RSAPrivateKeyType:
+			key = mustd.Must1(x509.ParsePKCS1PrivateKey(b.Bytes))
+		case ECPrivateKeyType:
+			key = mustd.Must1(x509.ParseECPrivateKey(b.Bytes))
+		default:
+			continue
+		}
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			panic(fmt.Errorf("pemmust: unsupported private key type %T", key))
+		}
+		return signer
+	}
+	panic(fmt.Errorf("pemmust: no private key block found"))
+}
+
+// ParsePublicKey returns the public key in the first PUBLIC KEY block in data, which is encoded in PKIX.
+// Panics if an error occurs or no public key is found.
+func ParsePublicKey(data []byte) crypto.PublicKey {
+	for _, b := range DecodeAll(data) {
+		if b.Type == PublicKeyType {
+			return mustd.Must1(x509.ParsePKIXPublicKey(b.Bytes))
+		}
+	}
+	panic(fmt.Errorf("pemmust: no %s block found", PublicKeyType))
+}
+
+// MarshalCertificate returns the PEM encoding of cert in a CERTIFICATE block.
+func MarshalCertificate(cert *x509.Certificate) []byte {
+	return EncodeToMemory(&pem.Block{Type: CertificateType, Bytes: cert.Raw})
+}
+
+// MarshalPrivateKey returns the PEM encoding of key in a PRIVATE KEY block encoded in PKCS #8. Panics if an error occurs.
+func MarshalPrivateKey(key crypto.PrivateKey) []byte {
+	return EncodeToMemory(&pem.Block{Type: PrivateKeyType, Bytes: mustd.Must1(x509.MarshalPKCS8PrivateKey(key))})
+}
+
+// MarshalPublicKey returns the PEM encoding of key in a PUBLIC KEY block encoded in PKIX. Panics if an error occurs.
+func MarshalPublicKey(key crypto.PublicKey) []byte {
+	return EncodeToMemory(&pem.Block{Type: PublicKeyType, Bytes: mustd.Must1(x509.MarshalPKIXPublicKey(key))})
+}
+
+// ReadCertificateFile returns the certificates in the named PEM file. Panics if an error occurs or no certificate is found.
+func ReadCertificateFile(name string) []*x509.Certificate {
+	return ParseCertificates(mustd.Must1(os.ReadFile(name)))
+}
+
+// ReadPrivateKeyFile returns the private key in the named PEM file. Panics if an error occurs or no private key is found.
+func ReadPrivateKeyFile(name string) crypto.Signer {
+	return ParsePrivateKey(mustd.Must1(os.ReadFile(name)))
+}
@@ -0,0 +1,164 @@
+package pemmust_test
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Jumpaku/go-mustd/cryptomust/pemmust"
+	"github.com/Jumpaku/go-mustd/iomust"
+)
+
+// newCertificate returns a self-signed certificate and its private key.
+func newCertificate(t *testing.T) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "test"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+func expectPanic(t *testing.T, message string, f func()) {
+	t.Helper()
+	defer func() {
+		t.Helper()
+		err, _ := recover().(error)
+		if err == nil || !strings.Contains(err.Error(), message) {
+			t.Errorf("expected error containing %q, got %v", message, err)
+		}
+	}()
+	f()
+}
+
+func TestBlocks(t *testing.T) {
+	first := &pem.Block{Type: "FIRST", Headers: map[string]string{"Proc-Type": "4,ENCRYPTED"}, Bytes: []byte("first")}
+	second := &pem.Block{Type: "SECOND", Bytes: []byte("second")}
+
+	t.Run("Encode and DecodeAll", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		pemmust.Encode(iomust.WriterOf(buf), first)
+		pemmust.Encode(iomust.WriterOf(buf), second)
+		blocks := pemmust.DecodeAll(buf.Bytes())
+		if len(blocks) != 2 || blocks[0].Headers["Proc-Type"] != "4,ENCRYPTED" || string(blocks[1].Bytes) != "second" {
+			t.Errorf("unexpected blocks %v", blocks)
+		}
+		block, rest := pemmust.Decode(buf.Bytes())
+		if block.Type != "FIRST" || !bytes.Equal(rest, pemmust.EncodeToMemory(second)) {
+			t.Errorf("unexpected block %v and rest %q", block, rest)
+		}
+	})
+
+	t.Run("WriteFile and ReadFile", func(t *testing.T) {
+		name := filepath.Join(t.TempDir(), "blocks.pem")
+		pemmust.WriteFile(name, 0o600, first, second)
+		if blocks := pemmust.ReadFile(name); len(blocks) != 2 || blocks[0].Type != "FIRST" {
+			t.Errorf("unexpected blocks %v", blocks)
+		}
+	})
+
+	t.Run("no block panics", func(t *testing.T) {
+		expectPanic(t, "no PEM block found", func() { pemmust.Decode([]byte("not PEM")) })
+		expectPanic(t, "no PEM block found", func() { pemmust.DecodeAll(nil) })
+	})
+
+	t.Run("invalid headers panic", func(t *testing.T) {
+		expectPanic(t, "invalid headers", func() {
+			pemmust.EncodeToMemory(&pem.Block{Type: "X", Headers: map[string]string{"a:b": "c"}})
+		})
+	})
+}
+
+func TestCertificatesAndKeys(t *testing.T) {
+	cert, key := newCertificate(t)
+
+	t.Run("certificate", func(t *testing.T) {
+		data := append(pemmust.MarshalPrivateKey(key), pemmust.MarshalCertificate(cert)...)
+		if got := pemmust.ParseCertificate(data); !got.Equal(cert) {
+			t.Error("parsed certificate differs")
+		}
+		name := filepath.Join(t.TempDir(), "cert.pem")
+		pemmust.WriteFile(name, 0o644, pemmust.DecodeAll(pemmust.MarshalCertificate(cert))...)
+		if certs := pemmust.ReadCertificateFile(name); len(certs) != 1 || !certs[0].Equal(cert) {
+			t.Errorf("unexpected certificates %v", certs)
+		}
+	})
+
+	t.Run("private keys", func(t *testing.T) {
+		rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, edKey, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		encodings := map[string][]byte{
+			"PKCS #8 ECDSA":   pemmust.MarshalPrivateKey(key),
+			"PKCS #8 Ed25519": pemmust.MarshalPrivateKey(edKey),
+			"PKCS #1 RSA":     pem.EncodeToMemory(&pem.Block{Type: pemmust.RSAPrivateKeyType, Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)}),
+		}
+		ecDER, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		encodings["SEC 1 ECDSA"] = pem.EncodeToMemory(&pem.Block{Type: pemmust.ECPrivateKeyType, Bytes: ecDER})
+		for name, data := range encodings {
+			t.Run(name, func(t *testing.T) {
+				signer := pemmust.ParsePrivateKey(append(pemmust.MarshalCertificate(cert), data...))
+				if signer == nil {
+					t.Error("no private key parsed")
+				}
+			})
+		}
+		name := filepath.Join(t.TempDir(), "key.pem")
+		pemmust.WriteFile(name, 0o600, pemmust.DecodeAll(pemmust.MarshalPrivateKey(key))...)
+		if got := pemmust.ReadPrivateKeyFile(name); !key.Equal(got) {
+			t.Error("read private key differs")
+		}
+	})
+
+	t.Run("public key", func(t *testing.T) {
+		if got := pemmust.ParsePublicKey(pemmust.MarshalPublicKey(key.Public())); !key.PublicKey.Equal(got) {
+			t.Error("parsed public key differs")
+		}
+	})
+
+	t.Run("missing blocks panic", func(t *testing.T) {
+		keyPEM := pemmust.MarshalPrivateKey(key)
+		expectPanic(t, "no CERTIFICATE block found", func() { pemmust.ParseCertificate(keyPEM) })
+		expectPanic(t, "no PUBLIC KEY block found", func() { pemmust.ParsePublicKey(keyPEM) })
+		expectPanic(t, "no private key block found", func() { pemmust.ParsePrivateKey(pemmust.MarshalCertificate(cert)) })
+	})
+
+	t.Run("corrupt certificate panics", func(t *testing.T) {
+		expectPanic(t, "x509", func() {
+			pemmust.ParseCertificate(pem.EncodeToMemory(&pem.Block{Type: pemmust.CertificateType, Bytes: []byte("corrupt")}))
+		})
+	})
+}
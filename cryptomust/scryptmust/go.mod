@@ -0,0 +1,10 @@
+module github.com/Jumpaku/go-mustd/cryptomust/scryptmust
+
+go 1.25.5
+
+require (
+	github.com/Jumpaku/go-mustd v0.0.0
+	golang.org/x/crypto v0.54.0
+)
+
+replace github.com/Jumpaku/go-mustd => ../..
@@ -0,0 +1,29 @@
+// Package scryptmust provides a wrapper for the golang.org/x/crypto/scrypt package with panicking error handling,
+// and a KDF of the cryptomust package using scrypt.
+package scryptmust
+
+import (
+	"github.com/Jumpaku/go-mustd"
+	"github.com/Jumpaku/go-mustd/cryptomust"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Recommended parameters of scrypt for interactive logins.
+const (
+	DefaultN = 1 << 15
+	DefaultR = 8
+	DefaultP = 1
+)
+
+// Key derives a key of keyLen bytes from password and salt with the CPU/memory cost N, the block size r and the parallelization p.
+// Panics if the parameters are invalid.
+func Key(password, salt []byte, N, r, p, keyLen int) []byte {
+	return mustd.Must1(scrypt.Key(password, salt, N, r, p, keyLen))
+}
+
+// KDF returns a cryptomust.KDF using scrypt with the parameters N, r and p, which is suitable for passphrases.
+func KDF(N, r, p int) cryptomust.KDF {
+	return func(secret, salt []byte, keyLength int) []byte {
+		return Key(secret, salt, N, r, p, keyLength)
+	}
+}
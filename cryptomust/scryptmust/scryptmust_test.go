@@ -0,0 +1,42 @@
+package scryptmust_test
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/Jumpaku/go-mustd/cryptomust"
+	"github.com/Jumpaku/go-mustd/cryptomust/scryptmust"
+)
+
+func TestKey(t *testing.T) {
+	t.Run("RFC 7914 test vector", func(t *testing.T) {
+		key := scryptmust.Key([]byte("password"), []byte("NaCl"), 1024, 8, 16, 64)
+		expected := "fdbabe1c9d3472007856e7190d01e9fe7c6ad7cbc8237830e77376634b3731622eaf30d92e22a3886ff109279d9830dac727afb94a83ee6d8360cbdfa2cc0640"
+		if got := hex.EncodeToString(key); got != expected {
+			t.Errorf("expected %s, got %s", expected, got)
+		}
+	})
+
+	t.Run("invalid parameters panic", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("Key did not panic")
+			}
+		}()
+		scryptmust.Key([]byte("password"), []byte("salt"), 1000, 8, 1, 32)
+	})
+}
+
+func TestKDF(t *testing.T) {
+	kdf := scryptmust.KDF(1<<10, scryptmust.DefaultR, scryptmust.DefaultP)
+	sealed := cryptomust.Seal(kdf, []byte("passphrase"), []byte("secret"))
+	if got := string(cryptomust.Open(kdf, []byte("passphrase"), sealed)); got != "secret" {
+		t.Errorf("unexpected plaintext %q", got)
+	}
+	defer func() {
+		if _, ok := recover().(*cryptomust.AuthError); !ok {
+			t.Error("expected *cryptomust.AuthError")
+		}
+	}()
+	cryptomust.Open(kdf, []byte("wrong passphrase"), sealed)
+}
@@ -0,0 +1,90 @@
+// Package base32must provides wrappers for the encoding/base32 package with panicking error handling.
+package base32must
+
+import (
+	"encoding/base32"
+
+	"github.com/Jumpaku/go-mustd"
+	"github.com/Jumpaku/go-mustd/iomust"
+)
+
+// StdEncoding is the standard base32 encoding.
+var StdEncoding = NewEncoding("ABCDEFGHIJKLMNOPQRSTUVWXYZ234567")
+
+// HexEncoding is the "Extended Hex Alphabet" base32 encoding.
+var HexEncoding = NewEncoding("0123456789ABCDEFGHIJKLMNOPQRSTUV")
+
+// NewDecoder returns a new base32 stream decoder.
+func NewDecoder(enc *Encoding, r iomust.Reader) iomust.Reader {
+	return enc.NewDecoder(r)
+}
+
+// NewEncoder returns a new base32 stream encoder. Close must be called to write any partially written blocks.
+func NewEncoder(enc *Encoding, w iomust.Writer) iomust.WriteCloser {
+	return enc.NewEncoder(w)
+}
+
+// Encoding wraps base32.Encoding and provides panicking error handling.
+type Encoding struct {
+	encoding *base32.Encoding
+}
+
+// NewEncoding returns a new Encoding defined by the given alphabet.
+func NewEncoding(encoder string) *Encoding {
+	return &Encoding{encoding: base32.NewEncoding(encoder)}
+}
+
+// AppendDecode appends the base32 decoded src to dst. Panics if an error occurs.
+func (enc *Encoding) AppendDecode(dst, src []byte) []byte {
+	return mustd.Must1(enc.encoding.AppendDecode(dst, src))
+}
+
+// AppendEncode appends the base32 encoded src to dst.
+func (enc *Encoding) AppendEncode(dst, src []byte) []byte {
+	return enc.encoding.AppendEncode(dst, src)
+}
+
+// Decode decodes src into dst. Panics if an error occurs.
+func (enc *Encoding) Decode(dst, src []byte) (n int) {
+	return mustd.Must1(enc.encoding.Decode(dst, src))
+}
+
+// DecodeString returns the bytes represented by the base32 string s. Panics if an error occurs.
+func (enc *Encoding) DecodeString(s string) []byte {
+	return mustd.Must1(enc.encoding.DecodeString(s))
+}
+
+// NewDecoder returns a new base32 stream decoder using enc.
+func (enc *Encoding) NewDecoder(r iomust.Reader) iomust.Reader {
+	return iomust.ReaderOf(base32.NewDecoder(enc.encoding, r.Reader()))
+}
+
+// NewEncoder returns a new base32 stream encoder using enc. Close must be called to write any partially written blocks.
+func (enc *Encoding) NewEncoder(w iomust.Writer) iomust.WriteCloser {
+	return iomust.WriteCloserOf(base32.NewEncoder(enc.encoding, w.Writer()))
+}
+
+// DecodedLen returns the maximum length in bytes of the decoded data.
+func (enc *Encoding) DecodedLen(n int) int {
+	return enc.encoding.DecodedLen(n)
+}
+
+// Encode encodes src into dst.
+func (enc *Encoding) Encode(dst, src []byte) {
+	enc.encoding.Encode(dst, src)
+}
+
+// EncodeToString returns the base32 encoding of src.
+func (enc *Encoding) EncodeToString(src []byte) string {
+	return enc.encoding.EncodeToString(src)
+}
+
+// EncodedLen returns the length in bytes of the base32 encoding of n bytes.
+func (enc *Encoding) EncodedLen(n int) int {
+	return enc.encoding.EncodedLen(n)
+}
+
+// WithPadding creates a new encoding identical to enc except with specified padding character.
+func (enc Encoding) WithPadding(padding rune) *Encoding {
+	return &Encoding{encoding: enc.encoding.WithPadding(padding)}
+}
@@ -0,0 +1,71 @@
+package base32must_test
+
+import (
+	"bytes"
+	"encoding/base32"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/Jumpaku/go-mustd/encodingmust/base32must"
+	"github.com/Jumpaku/go-mustd/iomust"
+)
+
+func TestEncoding(t *testing.T) {
+	t.Run("EncodeToString and DecodeString", func(t *testing.T) {
+		encoded := base32must.StdEncoding.EncodeToString([]byte("hello"))
+		if encoded != "NBSWY3DP" {
+			t.Errorf("expected NBSWY3DP, got %s", encoded)
+		}
+		if decoded := base32must.StdEncoding.DecodeString(encoded); string(decoded) != "hello" {
+			t.Errorf("expected hello, got %s", decoded)
+		}
+	})
+
+	t.Run("DecodeString invalid data panics", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("DecodeString did not panic with invalid data")
+			}
+		}()
+		base32must.StdEncoding.DecodeString("!!!invalid!!!")
+	})
+
+	t.Run("Encode and Decode", func(t *testing.T) {
+		data := []byte("hello world")
+		dst := make([]byte, base32must.HexEncoding.EncodedLen(len(data)))
+		base32must.HexEncoding.Encode(dst, data)
+		decoded := make([]byte, base32must.HexEncoding.DecodedLen(len(dst)))
+		n := base32must.HexEncoding.Decode(decoded, dst)
+		if !bytes.Equal(decoded[:n], data) {
+			t.Errorf("expected %s, got %s", data, decoded[:n])
+		}
+	})
+
+	t.Run("AppendEncode and AppendDecode", func(t *testing.T) {
+		encoded := base32must.StdEncoding.AppendEncode([]byte("prefix:"), []byte("hi"))
+		decoded := base32must.StdEncoding.AppendDecode([]byte("prefix:"), bytes.TrimPrefix(encoded, []byte("prefix:")))
+		if string(decoded) != "prefix:hi" {
+			t.Errorf("expected prefix:hi, got %s", decoded)
+		}
+	})
+
+	t.Run("WithPadding", func(t *testing.T) {
+		encoded := base32must.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("a"))
+		if strings.Contains(encoded, "=") {
+			t.Errorf("encoded string contains padding: %s", encoded)
+		}
+	})
+}
+
+func TestStream(t *testing.T) {
+	buf := &bytes.Buffer{}
+	enc := base32must.NewEncoder(base32must.StdEncoding, iomust.WriterOf(buf))
+	enc.Write([]byte("hello world"))
+	enc.Close()
+	dec := base32must.NewDecoder(base32must.StdEncoding, iomust.ReaderOf(buf))
+	decoded, err := io.ReadAll(dec.Reader())
+	if err != nil || string(decoded) != "hello world" {
+		t.Errorf("expected hello world, got %s (%v)", decoded, err)
+	}
+}
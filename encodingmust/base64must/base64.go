@@ -3,6 +3,8 @@ package base64must
 
 import (
 	"encoding/base64"
+	"io"
+	"strings"
 
 	"github.com/Jumpaku/go-mustd"
 	"github.com/Jumpaku/go-mustd/iomust"
@@ -19,14 +21,53 @@ var RawStdEncoding = StdEncoding.WithPadding(base64.NoPadding)
 
 // RawURLEncoding is the URL-safe base64 encoding without padding.
 var RawURLEncoding = URLEncoding.WithPadding(base64.NoPadding)
+
+// MIMELineLength is the maximum length of encoded lines in MIME (RFC 2045).
+const MIMELineLength = 76
+
 // NewDecoder returns a new base64 stream decoder.
-func NewDecoder(enc *base64.Encoding, r iomust.Reader) iomust.Reader {
-	return iomust.ReaderOf(base64.NewDecoder(enc, r.Reader()))
+func NewDecoder(enc *Encoding, r iomust.Reader) iomust.Reader {
+	return enc.NewDecoder(r)
+}
+
+// NewEncoder returns a new base64 stream encoder. Close must be called to write any partially written blocks.
+func NewEncoder(enc *Encoding, w iomust.Writer, opts ...EncoderOption) iomust.WriteCloser {
+	return enc.NewEncoder(w, opts...)
+}
+
+// EncoderOption configures a stream encoder created by NewEncoder.
+type EncoderOption func(*encoderOptions)
+
+type encoderOptions struct {
+	lineLength    int
+	lineSeparator string
 }
 
-// NewEncoder returns a new base64 stream encoder.
-func NewEncoder(enc *base64.Encoding, w iomust.Writer) iomust.WriteCloser {
-	return iomust.WriteCloserOf(base64.NewEncoder(enc, w.Writer()))
+// WrapLines returns an EncoderOption that breaks the encoded output into lines of length characters separated by separator.
+func WrapLines(length int, separator string) EncoderOption {
+	return func(o *encoderOptions) {
+		o.lineLength = length
+		o.lineSeparator = separator
+	}
+}
+
+// MIME returns an EncoderOption that breaks the encoded output into lines of MIMELineLength characters separated by CRLF as MIME.
+func MIME() EncoderOption {
+	return WrapLines(MIMELineLength, "\r\n")
+}
+
+// DecodeAny returns the bytes represented by the base64 string s in the standard or URL-safe alphabet, with or without padding.
+// White space in s is ignored. Panics if an error occurs.
+func DecodeAny(s string) []byte {
+	s = strings.Join(strings.Fields(s), "")
+	enc := StdEncoding
+	if strings.ContainsAny(s, "-_") {
+		enc = URLEncoding
+	}
+	if !strings.HasSuffix(s, "=") {
+		enc = enc.WithPadding(base64.NoPadding)
+	}
+	return enc.DecodeString(s)
 }
 
 // Encoding wraps base64.Encoding and provides panicking error handling.
@@ -59,6 +100,24 @@ func (enc *Encoding) DecodeString(s string) []byte {
 	return mustd.Must1(enc.encoding.DecodeString(s))
 }
 
+// NewDecoder returns a new base64 stream decoder using enc.
+func (enc *Encoding) NewDecoder(r iomust.Reader) iomust.Reader {
+	return iomust.ReaderOf(base64.NewDecoder(enc.encoding, r.Reader()))
+}
+
+// NewEncoder returns a new base64 stream encoder using enc. Close must be called to write any partially written blocks.
+func (enc *Encoding) NewEncoder(w iomust.Writer, opts ...EncoderOption) iomust.WriteCloser {
+	var o encoderOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	output := w.Writer()
+	if o.lineLength > 0 {
+		output = &lineWrapper{writer: output, length: o.lineLength, separator: []byte(o.lineSeparator)}
+	}
+	return iomust.WriteCloserOf(base64.NewEncoder(enc.encoding, output))
+}
+
 // DecodedLen returns the maximum length in bytes of the decoded data.
 func (enc *Encoding) DecodedLen(n int) int {
 	return enc.encoding.DecodedLen(n)
@@ -88,3 +147,31 @@ func (enc Encoding) Strict() *Encoding {
 func (enc Encoding) WithPadding(padding rune) *Encoding {
 	return &Encoding{encoding: enc.encoding.WithPadding(padding)}
 }
+
+// lineWrapper writes to the underlying writer inserting separator after every length bytes.
+type lineWrapper struct {
+	writer    io.Writer
+	length    int
+	separator []byte
+	column    int
+}
+
+func (w *lineWrapper) Write(p []byte) (n int, err error) {
+	for len(p) > 0 {
+		if w.column == w.length {
+			if _, err := w.writer.Write(w.separator); err != nil {
+				return n, err
+			}
+			w.column = 0
+		}
+		chunk := p[:min(len(p), w.length-w.column)]
+		written, err := w.writer.Write(chunk)
+		n += written
+		w.column += written
+		if err != nil {
+			return n, err
+		}
+		p = p[written:]
+	}
+	return n, nil
+}
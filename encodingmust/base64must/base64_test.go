@@ -176,7 +176,7 @@ func TestNewEncoder(t *testing.T) {
 	t.Run("encode stream", func(t *testing.T) {
 		buf := &bytes.Buffer{}
 		w := iomust.WriterOf(buf)
-		enc := base64must.NewEncoder(base64.StdEncoding, w)
+		enc := base64must.NewEncoder(base64must.StdEncoding, w)
 
 		n := enc.Write([]byte("hello"))
 		if n != 5 {
@@ -196,7 +196,7 @@ func TestNewDecoder(t *testing.T) {
 	t.Run("decode stream", func(t *testing.T) {
 		encoded := base64must.StdEncoding.EncodeToString([]byte("hello world"))
 		r := iomust.ReaderOf(strings.NewReader(encoded))
-		dec := base64must.NewDecoder(base64.StdEncoding, r)
+		dec := base64must.NewDecoder(base64must.StdEncoding, r)
 
 		decoded := iomust.ReadAll(dec)
 		if !bytes.Equal(decoded, []byte("hello world")) {
@@ -204,3 +204,74 @@ func TestNewDecoder(t *testing.T) {
 		}
 	})
 }
+
+func TestEncodingStream(t *testing.T) {
+	t.Run("NewEncoder and NewDecoder methods", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		enc := base64must.RawURLEncoding.NewEncoder(iomust.WriterOf(buf))
+		enc.Write([]byte{0xfb, 0xff, 0xfe})
+		enc.Close()
+		if buf.String() != "-__-" {
+			t.Errorf("expected -__-, got %s", buf.String())
+		}
+		decoded := iomust.ReadAll(base64must.RawURLEncoding.NewDecoder(iomust.ReaderOf(buf)))
+		if !bytes.Equal(decoded, []byte{0xfb, 0xff, 0xfe}) {
+			t.Errorf("unexpected decoded data %x", decoded)
+		}
+	})
+
+	t.Run("MIME line wrapping", func(t *testing.T) {
+		data := bytes.Repeat([]byte("0123456789"), 12)
+		buf := &bytes.Buffer{}
+		enc := base64must.NewEncoder(base64must.StdEncoding, iomust.WriterOf(buf), base64must.MIME())
+		enc.Write(data[:7])
+		enc.Write(data[7:])
+		enc.Close()
+		lines := strings.Split(buf.String(), "\r\n")
+		if len(lines) != 3 || len(lines[0]) != base64must.MIMELineLength || len(lines[1]) != base64must.MIMELineLength {
+			t.Errorf("unexpected lines %q", lines)
+		}
+		if strings.Join(lines, "") != base64must.StdEncoding.EncodeToString(data) {
+			t.Errorf("wrapped output differs from unwrapped encoding")
+		}
+		if !bytes.Equal(base64must.DecodeAny(buf.String()), data) {
+			t.Errorf("DecodeAny did not decode wrapped output")
+		}
+	})
+
+	t.Run("WrapLines", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		enc := base64must.NewEncoder(base64must.StdEncoding, iomust.WriterOf(buf), base64must.WrapLines(4, "\n"))
+		enc.Write([]byte("hello"))
+		enc.Close()
+		if buf.String() != "aGVs\nbG8=" {
+			t.Errorf("expected %q, got %q", "aGVs\nbG8=", buf.String())
+		}
+	})
+}
+
+func TestDecodeAny(t *testing.T) {
+	data := []byte{0xfb, 0xff, 0xbf, 'h', 'i'}
+	encodings := map[string]*base64must.Encoding{
+		"StdEncoding":    base64must.StdEncoding,
+		"URLEncoding":    base64must.URLEncoding,
+		"RawStdEncoding": base64must.RawStdEncoding,
+		"RawURLEncoding": base64must.RawURLEncoding,
+	}
+	for name, enc := range encodings {
+		t.Run(name, func(t *testing.T) {
+			if decoded := base64must.DecodeAny(enc.EncodeToString(data)); !bytes.Equal(decoded, data) {
+				t.Errorf("expected %x, got %x", data, decoded)
+			}
+		})
+	}
+
+	t.Run("invalid data panics", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("DecodeAny did not panic with invalid data")
+			}
+		}()
+		base64must.DecodeAny("a+b-")
+	})
+}
@@ -0,0 +1,124 @@
+// Package binarymust provides wrappers for the encoding/binary package with panicking error handling.
+package binarymust
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/Jumpaku/go-mustd"
+	"github.com/Jumpaku/go-mustd/iomust"
+)
+
+var errOverflow = errors.New("binarymust: varint overflows a 64-bit integer")
+
+// Append appends the binary representation of data to buf. Panics if an error occurs.
+func Append(buf []byte, order binary.ByteOrder, data any) []byte {
+	return mustd.Must1(binary.Append(buf, order, data))
+}
+
+// AppendUvarint appends the varint-encoded form of x to buf.
+func AppendUvarint(buf []byte, x uint64) []byte {
+	return binary.AppendUvarint(buf, x)
+}
+
+// AppendVarint appends the varint-encoded form of x to buf.
+func AppendVarint(buf []byte, x int64) []byte {
+	return binary.AppendVarint(buf, x)
+}
+
+// Decode decodes binary data from buf into data according to the given byte order. Panics if an error occurs.
+func Decode(buf []byte, order binary.ByteOrder, data any) int {
+	return mustd.Must1(binary.Decode(buf, order, data))
+}
+
+// Encode encodes the binary representation of data into buf according to the given byte order. Panics if an error occurs.
+func Encode(buf []byte, order binary.ByteOrder, data any) int {
+	return mustd.Must1(binary.Encode(buf, order, data))
+}
+
+// PutUvarint encodes x into buf and returns the number of bytes written. Panics if buf is too small.
+func PutUvarint(buf []byte, x uint64) int {
+	return binary.PutUvarint(buf, x)
+}
+
+// PutVarint encodes x into buf and returns the number of bytes written. Panics if buf is too small.
+func PutVarint(buf []byte, x int64) int {
+	return binary.PutVarint(buf, x)
+}
+
+// Read reads structured binary data from r into data according to the given byte order. Panics if an error occurs.
+func Read(r iomust.Reader, order binary.ByteOrder, data any) {
+	mustd.Must0(binary.Read(r.Reader(), order, data))
+}
+
+// ReadUvarint reads an encoded unsigned integer from r. Panics if an error occurs, including io.EOF and overflow.
+// If r does not implement io.ByteReader, it is read byte by byte so that no bytes following the integer are consumed.
+func ReadUvarint(r iomust.Reader) uint64 {
+	return mustd.Must1(binary.ReadUvarint(byteReaderOf(r.Reader())))
+}
+
+// ReadVarint reads an encoded signed integer from r. Panics if an error occurs, including io.EOF and overflow.
+// If r does not implement io.ByteReader, it is read byte by byte so that no bytes following the integer are consumed.
+func ReadVarint(r iomust.Reader) int64 {
+	return mustd.Must1(binary.ReadVarint(byteReaderOf(r.Reader())))
+}
+
+// Size returns how many bytes Write would generate to encode the value v. Panics if v is not a fixed-size value.
+func Size(v any) int {
+	size := binary.Size(v)
+	if size < 0 {
+		panic(errors.New("binarymust: invalid type for binary size"))
+	}
+	return size
+}
+
+// Uvarint decodes an unsigned integer from buf and returns it with the number of bytes read. Panics if buf is too small or the value overflows.
+func Uvarint(buf []byte) (uint64, int) {
+	x, n := binary.Uvarint(buf)
+	mustd.Must0(varintError(n))
+	return x, n
+}
+
+// Varint decodes a signed integer from buf and returns it with the number of bytes read. Panics if buf is too small or the value overflows.
+func Varint(buf []byte) (int64, int) {
+	x, n := binary.Varint(buf)
+	mustd.Must0(varintError(n))
+	return x, n
+}
+
+// Write writes the binary representation of data into w according to the given byte order. Panics if an error occurs.
+func Write(w iomust.Writer, order binary.ByteOrder, data any) {
+	mustd.Must0(binary.Write(w.Writer(), order, data))
+}
+
+// varintError returns the error indicated by the number of bytes n returned by binary.Uvarint or binary.Varint.
+func varintError(n int) error {
+	switch {
+	case n == 0:
+		return io.ErrUnexpectedEOF
+	case n < 0:
+		return errOverflow
+	}
+	return nil
+}
+
+// byteReaderOf returns r as an io.ByteReader, reading one byte at a time if r does not implement it.
+func byteReaderOf(r io.Reader) io.ByteReader {
+	if br, ok := r.(io.ByteReader); ok {
+		return br
+	}
+	return &byteReader{reader: r}
+}
+
+type byteReader struct {
+	reader io.Reader
+	buf    [1]byte
+}
+
+func (r *byteReader) ReadByte() (byte, error) {
+	if _, err := io.ReadFull(r.reader, r.buf[:]); err != nil {
+		return 0, err
+	}
+	return r.buf[0], nil
+}
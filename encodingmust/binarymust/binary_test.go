@@ -0,0 +1,121 @@
+package binarymust_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/Jumpaku/go-mustd/encodingmust/binarymust"
+	"github.com/Jumpaku/go-mustd/iomust"
+)
+
+type header struct {
+	Magic   [4]byte
+	Version uint16
+	Flags   uint16
+	Size    uint32
+}
+
+func TestReadWrite(t *testing.T) {
+	t.Run("struct round trip", func(t *testing.T) {
+		h := header{Magic: [4]byte{'F', 'W', '0', '1'}, Version: 2, Flags: 0x8001, Size: 4096}
+		buf := &bytes.Buffer{}
+		binarymust.Write(iomust.WriterOf(buf), binary.BigEndian, h)
+		if buf.Len() != binarymust.Size(h) {
+			t.Errorf("expected %d bytes, got %d", binarymust.Size(h), buf.Len())
+		}
+		var got header
+		binarymust.Read(iomust.ReaderOf(buf), binary.BigEndian, &got)
+		if got != h {
+			t.Errorf("expected %+v, got %+v", h, got)
+		}
+	})
+
+	t.Run("Read short input panics", func(t *testing.T) {
+		defer func() {
+			err, _ := recover().(error)
+			if !errors.Is(err, io.ErrUnexpectedEOF) {
+				t.Errorf("expected io.ErrUnexpectedEOF, got %v", err)
+			}
+		}()
+		var h header
+		binarymust.Read(iomust.ReaderOf(bytes.NewReader([]byte("FW01\x00"))), binary.BigEndian, &h)
+	})
+
+	t.Run("Append, Encode and Decode", func(t *testing.T) {
+		data := binarymust.Append([]byte{0xff}, binary.LittleEndian, uint16(0x0102))
+		if !bytes.Equal(data, []byte{0xff, 0x02, 0x01}) {
+			t.Errorf("unexpected data %x", data)
+		}
+		buf := make([]byte, 4)
+		binarymust.Encode(buf, binary.LittleEndian, uint32(7))
+		var v uint32
+		binarymust.Decode(buf, binary.LittleEndian, &v)
+		if v != 7 {
+			t.Errorf("expected 7, got %d", v)
+		}
+	})
+
+	t.Run("Size of invalid type panics", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("Size did not panic")
+			}
+		}()
+		binarymust.Size("string")
+	})
+}
+
+// onlyReader hides io.ByteReader of the underlying reader.
+type onlyReader struct{ io.Reader }
+
+func TestVarint(t *testing.T) {
+	t.Run("Append and decode", func(t *testing.T) {
+		buf := binarymust.AppendUvarint(nil, 300)
+		buf = binarymust.AppendVarint(buf, -5)
+		x, n := binarymust.Uvarint(buf)
+		y, m := binarymust.Varint(buf[n:])
+		if x != 300 || y != -5 || n+m != len(buf) {
+			t.Errorf("unexpected values %d, %d with lengths %d, %d", x, y, n, m)
+		}
+	})
+
+	t.Run("ReadUvarint and ReadVarint", func(t *testing.T) {
+		buf := binarymust.AppendVarint(binarymust.AppendUvarint(nil, 1<<40), -1<<40)
+		buf = append(buf, 0xaa)
+		r := iomust.ReaderOf(onlyReader{bytes.NewReader(buf)})
+		if x := binarymust.ReadUvarint(r); x != 1<<40 {
+			t.Errorf("expected %d, got %d", uint64(1<<40), x)
+		}
+		if y := binarymust.ReadVarint(r); y != -1<<40 {
+			t.Errorf("expected %d, got %d", int64(-1<<40), y)
+		}
+		rest, _ := io.ReadAll(r.Reader())
+		if !bytes.Equal(rest, []byte{0xaa}) {
+			t.Errorf("expected trailing byte to remain, got %x", rest)
+		}
+	})
+
+	overflow := bytes.Repeat([]byte{0xff}, 11)
+	testCases := []struct {
+		name string
+		f    func()
+	}{
+		{name: "Uvarint overflow", f: func() { binarymust.Uvarint(overflow) }},
+		{name: "Varint short buffer", f: func() { binarymust.Varint([]byte{0x80}) }},
+		{name: "ReadUvarint overflow", f: func() { binarymust.ReadUvarint(iomust.ReaderOf(bytes.NewReader(overflow))) }},
+		{name: "ReadVarint truncated", f: func() { binarymust.ReadVarint(iomust.ReaderOf(bytes.NewReader([]byte{0x80}))) }},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("%s did not panic", tc.name)
+				}
+			}()
+			tc.f()
+		})
+	}
+}
@@ -2,20 +2,57 @@
 package csvmust
 
 import (
+	"bufio"
 	"encoding/csv"
+	"errors"
+	"io"
+	"iter"
 
 	"github.com/Jumpaku/go-mustd"
+	"github.com/Jumpaku/go-mustd/encodingmust"
 	"github.com/Jumpaku/go-mustd/iomust"
 )
 
 // Reader wraps encoding/csv.Reader and provides panicking error handling for CSV reading operations.
+// Parse errors are reported as *encodingmust.DecodeError with the line, the column and an excerpt of the input.
 type Reader struct {
 	csv.Reader
+	position *encodingmust.PositionReader
 }
 
-// NewReader returns a new Reader that reads from r.
-func NewReader(r iomust.Reader) *Reader {
-	return &Reader{Reader: *csv.NewReader(r.Reader())}
+// NewReader returns a new Reader that reads from r in the RFC4180 dialect unless configured by opts.
+// A UTF-8 byte order mark at the beginning of the input is stripped, and input beginning with a UTF-16 byte order mark is decoded into UTF-8.
+// Panics if an error occurs reading the input for WithSniffing.
+func NewReader(r iomust.Reader, opts ...Option) *Reader {
+	o := newOptions(opts)
+	var input io.Reader = newBOMReader(r.Reader())
+	if o.sniff {
+		buffered := bufio.NewReaderSize(input, sniffSize)
+		sample, err := buffered.Peek(sniffSize)
+		if err != nil && err != io.EOF {
+			mustd.Must0(err)
+		}
+		if comma, ok := sniffComma(sample, err == io.EOF); ok {
+			o.dialect.Comma = comma
+		}
+		input = buffered
+	}
+	position := encodingmust.NewPositionReader(input)
+	reader := &Reader{Reader: *csv.NewReader(position), position: position}
+	reader.Comma = o.dialect.Comma
+	reader.Comment = o.dialect.Comment
+	reader.LazyQuotes = o.dialect.LazyQuotes
+	reader.TrimLeadingSpace = o.dialect.TrimLeadingSpace
+	return reader
+}
+
+// locate returns err with its location in the input if err is a *csv.ParseError.
+func (r *Reader) locate(err error) error {
+	var parseErr *csv.ParseError
+	if !errors.As(err, &parseErr) {
+		return err
+	}
+	return r.position.DecodeErrorAt(parseErr.Line, parseErr.Column, parseErr.Err)
 }
 
 // FieldPos returns the line and column of the specified field.
@@ -24,33 +61,56 @@ func (r *Reader) FieldPos(field int) (line, column int) {
 }
 
 // InputOffset returns the input offset of the reader.
+// It counts bytes of the input after a UTF-8 byte order mark is stripped or UTF-16 input is decoded into UTF-8.
 func (r *Reader) InputOffset() int64 {
 	return r.Reader.InputOffset()
 }
 
 // Read reads one record from the CSV input. Panics if an error occurs.
 func (r *Reader) Read() (record []string) {
-	return mustd.Must1(r.Reader.Read())
+	record, err := r.Reader.Read()
+	mustd.Must0(r.locate(err))
+	return record
 }
 
 // ReadAll reads all records from the CSV input. Panics if an error occurs.
 func (r *Reader) ReadAll() (records [][]string) {
-	return mustd.Must1(r.Reader.ReadAll())
+	records, err := r.Reader.ReadAll()
+	mustd.Must0(r.locate(err))
+	return records
 }
 
 // Writer wraps encoding/csv.Writer and provides panicking error handling for CSV writing operations.
 type Writer struct {
 	csv.Writer
+	output io.Writer
 }
 
-// NewWriter returns a new Writer that writes to w.
-func NewWriter(w iomust.Writer) *Writer {
-	return &Writer{Writer: *csv.NewWriter(w.Writer())}
+// NewWriter returns a new Writer that writes to w in the RFC4180 dialect unless configured by opts.
+func NewWriter(w iomust.Writer, opts ...Option) *Writer {
+	o := newOptions(opts)
+	var output io.Writer = w.Writer()
+	if o.dialect.BOM {
+		output = &bomWriter{writer: output}
+	}
+	writer := &Writer{Writer: *csv.NewWriter(output), output: w.Writer()}
+	writer.Comma = o.dialect.Comma
+	writer.UseCRLF = o.dialect.UseCRLF
+	return writer
 }
 
-// Flush writes any buffered data to the underlying writer.
+// Flush writes any buffered data to the underlying writer. Panics if an error occurs.
 func (w *Writer) Flush() {
 	w.Writer.Flush()
+	mustd.Must0(w.Writer.Error())
+}
+
+// Close flushes any buffered data and closes the underlying writer if it is an io.Closer. Panics if an error occurs.
+func (w *Writer) Close() {
+	w.Flush()
+	if closer, ok := w.output.(io.Closer); ok {
+		mustd.Must0(closer.Close())
+	}
 }
 
 // Write writes a single CSV record. Panics if an error occurs.
@@ -62,3 +122,11 @@ func (w *Writer) Write(record []string) {
 func (w *Writer) WriteAll(records [][]string) {
 	mustd.Must0(w.Writer.WriteAll(records))
 }
+
+// WriteSeq writes the CSV records yielded by records and flushes the writer. Panics if an error occurs.
+func (w *Writer) WriteSeq(records iter.Seq[[]string]) {
+	for record := range records {
+		w.Write(record)
+	}
+	w.Flush()
+}
@@ -2,9 +2,22 @@ package csvmust_test
 
 import (
 	"bytes"
+	"encoding/binary"
+	"encoding/csv"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
 	"strings"
 	"testing"
+	"testing/iotest"
+	"time"
+	"unicode/utf16"
 
+	"github.com/Jumpaku/go-mustd/encodingmust"
 	"github.com/Jumpaku/go-mustd/encodingmust/csvmust"
 	"github.com/Jumpaku/go-mustd/iomust"
 )
@@ -60,6 +73,127 @@ func TestReader(t *testing.T) {
 	})
 }
 
+func TestReaderDecodeError(t *testing.T) {
+	recoverDecodeError := func(t *testing.T, f func()) (decodeErr *encodingmust.DecodeError) {
+		t.Helper()
+		defer func() {
+			err, _ := recover().(error)
+			if !errors.As(err, &decodeErr) {
+				t.Fatalf("expected panic with *encodingmust.DecodeError, got %v", err)
+			}
+		}()
+		f()
+		return nil
+	}
+
+	t.Run("bare quote", func(t *testing.T) {
+		reader := csvmust.NewReader(iomust.ReaderOf(strings.NewReader("name,age\nAl\"ice,30\n")))
+		reader.Read()
+		err := recoverDecodeError(t, func() { reader.Read() })
+		if err.Line != 2 || err.Column != 3 {
+			t.Errorf("expected line 2, column 3, got line %d, column %d", err.Line, err.Column)
+		}
+		if err.Excerpt != "Al\"ice,30\n  ^" {
+			t.Errorf("unexpected excerpt %q", err.Excerpt)
+		}
+		if !errors.Is(err, csv.ErrBareQuote) {
+			t.Errorf("expected csv.ErrBareQuote, got %v", err.Err)
+		}
+	})
+
+	t.Run("field count in ReadAll", func(t *testing.T) {
+		reader := csvmust.NewReader(iomust.ReaderOf(strings.NewReader("a,b\nc,d\ne\n")))
+		err := recoverDecodeError(t, func() { reader.ReadAll() })
+		if err.Line != 3 {
+			t.Errorf("expected line 3, got line %d", err.Line)
+		}
+		if !errors.Is(err, csv.ErrFieldCount) {
+			t.Errorf("expected csv.ErrFieldCount, got %v", err.Err)
+		}
+	})
+}
+
+func TestDialect(t *testing.T) {
+	people := [][]string{{"name", "age", "city"}, {"Alice", "30", "Paris, France"}, {"Zoë", "25", "Tokyo"}}
+	tower := [][]string{{"name", "age", "city"}, {"Alice", "30", "Paris, France"}, {"Zoë", "25", "Tokyo 🗼"}}
+	testCases := []struct {
+		fixture string
+		opts    []csvmust.Option
+		want    [][]string
+	}{
+		{fixture: "excel.csv", opts: []csvmust.Option{csvmust.WithDialect(csvmust.Excel)}, want: people},
+		{fixture: "excel.csv", want: people},
+		{fixture: "semicolon.csv", opts: []csvmust.Option{csvmust.WithDialect(csvmust.Semicolon)}, want: people},
+		{fixture: "semicolon.csv", opts: []csvmust.Option{csvmust.WithSniffing()}, want: people},
+		{fixture: "people.tsv", opts: []csvmust.Option{csvmust.WithDialect(csvmust.TSV)}, want: people},
+		{fixture: "people.tsv", opts: []csvmust.Option{csvmust.WithSniffing()}, want: people},
+		{fixture: "utf16le.tsv", opts: []csvmust.Option{csvmust.WithDialect(csvmust.TSV)}, want: tower},
+		{fixture: "utf16le.tsv", opts: []csvmust.Option{csvmust.WithSniffing()}, want: tower},
+		{fixture: "utf16be.csv", want: tower},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.fixture, func(t *testing.T) {
+			f, err := os.Open(filepath.Join("testdata", tc.fixture))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			got := csvmust.NewReader(iomust.ReaderOf(f), tc.opts...).ReadAll()
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+
+	t.Run("UTF-16 larger than the buffer", func(t *testing.T) {
+		var want [][]string
+		var text strings.Builder
+		for i := range 2000 {
+			want = append(want, []string{strconv.Itoa(i), "Tokyo 🗼"})
+			text.WriteString(strconv.Itoa(i) + ",Tokyo 🗼\n")
+		}
+		data := []byte{0xFF, 0xFE}
+		for _, unit := range utf16.Encode([]rune(text.String())) {
+			data = binary.LittleEndian.AppendUint16(data, unit)
+		}
+		// Leading zeros are added to the first field so that a surrogate pair is split by the 4096-byte buffer of the reader.
+		pad := 4094 - bytes.LastIndex(data[:4096], []byte{0x3D, 0xD8})
+		data = slices.Insert(data, 2, bytes.Repeat([]byte{'0', 0}, pad/2)...)
+		want[0][0] = strings.Repeat("0", pad/2) + want[0][0]
+		if !bytes.Equal(data[4094:4098], []byte{0x3D, 0xD8, 0xFC, 0xDD}) {
+			t.Fatalf("surrogate pair is not split: % x", data[4094:4098])
+		}
+		for name, r := range map[string]io.Reader{"bulk": bytes.NewReader(data), "byte by byte": iotest.OneByteReader(bytes.NewReader(data))} {
+			got := csvmust.NewReader(iomust.ReaderOf(r)).ReadAll()
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("%s: unexpected records", name)
+			}
+		}
+	})
+
+	t.Run("Excel writer", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		w := csvmust.NewWriter(iomust.WriterOf(buf), csvmust.WithDialect(csvmust.Excel))
+		w.WriteAll(people)
+		want, err := os.ReadFile(filepath.Join("testdata", "excel.csv"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(buf.Bytes(), want) {
+			t.Errorf("expected %q, got %q", want, buf.Bytes())
+		}
+	})
+
+	t.Run("sniffing keeps other settings", func(t *testing.T) {
+		input := "# comment\na|b\nc|d\n"
+		r := csvmust.NewReader(iomust.ReaderOf(strings.NewReader(input)), csvmust.WithDialect(csvmust.Dialect{Comment: '#'}), csvmust.WithSniffing())
+		got := r.ReadAll()
+		if want := [][]string{{"a", "b"}, {"c", "d"}}; !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %q, got %q", want, got)
+		}
+	})
+}
+
 func TestWriter(t *testing.T) {
 	t.Run("Write single record", func(t *testing.T) {
 		buf := &bytes.Buffer{}
@@ -107,3 +241,241 @@ func TestWriter(t *testing.T) {
 		}
 	})
 }
+
+func TestRecordReader(t *testing.T) {
+	newRecordReader := func(s string) *csvmust.RecordReader {
+		return csvmust.NewRecordReader(csvmust.NewReader(iomust.ReaderOf(strings.NewReader(s))))
+	}
+
+	t.Run("Get and Map", func(t *testing.T) {
+		r := newRecordReader("name,age\nAlice,30\nBob,25\n")
+		if got := r.Header(); !reflect.DeepEqual(got, []string{"name", "age"}) {
+			t.Errorf("unexpected header %v", got)
+		}
+		var names []string
+		for record := range r.All() {
+			names = append(names, record.Get("name"))
+		}
+		if !reflect.DeepEqual(names, []string{"Alice", "Bob"}) {
+			t.Errorf("unexpected names %v", names)
+		}
+
+		m, ok := newRecordReader("name,age\nAlice,30\n").ReadMap()
+		if !ok || !reflect.DeepEqual(m, map[string]string{"name": "Alice", "age": "30"}) {
+			t.Errorf("unexpected map %v", m)
+		}
+	})
+
+	t.Run("Get missing column panics", func(t *testing.T) {
+		record, _ := newRecordReader("name\nAlice\n").Read()
+		defer func() {
+			err, ok := recover().(*csvmust.FieldError)
+			if !ok || err.Row != 2 || err.Header != "email" || !errors.Is(err, csvmust.ErrMissingColumn) {
+				t.Errorf("expected *csvmust.FieldError for email on row 2, got %v", err)
+			}
+		}()
+		record.Get("email")
+	})
+
+	t.Run("empty input panics", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("NewRecordReader did not panic on empty input")
+			}
+		}()
+		newRecordReader("")
+	})
+}
+
+type employee struct {
+	Name     string        `csv:"name"`
+	Age      int           `csv:"age"`
+	Rate     float64       `csv:"rate"`
+	Active   bool          `csv:"active"`
+	Joined   time.Time     `csv:"joined" layout:"2006-01-02"`
+	Shift    time.Duration `csv:"shift,omitempty"`
+	Manager  *string       `csv:"manager"`
+	Note     string        `csv:"note,optional"`
+	internal string
+	Ignored  string `csv:"-"`
+}
+
+func TestReadAllAs(t *testing.T) {
+	t.Run("maps columns to fields", func(t *testing.T) {
+		input := "age,name,rate,active,joined,shift,manager\n" +
+			"30,Alice,1.5,true,2024-04-01,8h,\n" +
+			"25,Bob,2,false,2023-10-15,,Alice\n"
+		r := csvmust.NewReader(iomust.ReaderOf(strings.NewReader(input)))
+		got := csvmust.ReadAllAs[employee](r)
+		manager := "Alice"
+		want := []employee{
+			{Name: "Alice", Age: 30, Rate: 1.5, Active: true, Joined: time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), Shift: 8 * time.Hour},
+			{Name: "Bob", Age: 25, Rate: 2, Joined: time.Date(2023, 10, 15, 0, 0, 0, 0, time.UTC), Manager: &manager},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %+v, got %+v", want, got)
+		}
+	})
+
+	t.Run("missing required column", func(t *testing.T) {
+		r := csvmust.NewReader(iomust.ReaderOf(strings.NewReader("name,age\nAlice,30\n")))
+		defer func() {
+			err, ok := recover().(*csvmust.FieldError)
+			if !ok || err.Row != 1 || err.Column != 0 || err.Header != "rate" || !errors.Is(err, csvmust.ErrMissingColumn) {
+				t.Errorf("expected *csvmust.FieldError for missing rate, got %v", err)
+			}
+		}()
+		csvmust.ReadAllAs[employee](r)
+	})
+
+	t.Run("conversion failure", func(t *testing.T) {
+		input := "name,age,rate,active,joined,shift,manager\n" +
+			"Alice,30,1.5,true,2024-04-01,8h,\n" +
+			"Bob,old,2,false,2023-10-15,,\n"
+		r := csvmust.NewReader(iomust.ReaderOf(strings.NewReader(input)))
+		defer func() {
+			err, ok := recover().(*csvmust.FieldError)
+			if !ok || err.Row != 3 || err.Column != 2 || err.Header != "age" {
+				t.Fatalf("expected *csvmust.FieldError for age on row 3, got %v", err)
+			}
+			if !errors.Is(err, strconv.ErrSyntax) {
+				t.Errorf("expected strconv.ErrSyntax, got %v", err.Err)
+			}
+			expected := `csvmust: row 3, column 2 ("age"): strconv.ParseInt: parsing "old": invalid syntax`
+			if err.Error() != expected {
+				t.Errorf("expected %q, got %q", expected, err.Error())
+			}
+		}()
+		csvmust.ReadAllAs[employee](r)
+	})
+}
+
+func TestWriteAllFrom(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := csvmust.NewWriter(iomust.WriterOf(buf))
+	manager := "Alice"
+	csvmust.WriteAllFrom(w, []employee{
+		{Name: "Alice", Age: 30, Rate: 1.5, Active: true, Joined: time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), Shift: 8 * time.Hour, Note: "lead"},
+		{Name: "Bob", Age: 25, Rate: 2, Joined: time.Date(2023, 10, 15, 0, 0, 0, 0, time.UTC), Manager: &manager},
+	})
+	expected := "name,age,rate,active,joined,shift,manager,note\n" +
+		"Alice,30,1.5,true,2024-04-01,8h0m0s,,lead\n" +
+		"Bob,25,2,false,2023-10-15,,Alice,\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+
+	r := csvmust.NewReader(iomust.ReaderOf(strings.NewReader(buf.String())))
+	got := csvmust.ReadAllAs[employee](r)
+	if len(got) != 2 || got[0].Shift != 8*time.Hour || got[1].Manager == nil || *got[1].Manager != "Alice" {
+		t.Errorf("unexpected round trip result %+v", got)
+	}
+}
+
+// level implements encoding.TextMarshaler and encoding.TextUnmarshaler with pointer receivers.
+type level struct {
+	n int
+}
+
+func (l *level) MarshalText() ([]byte, error) {
+	return []byte("L" + strconv.Itoa(l.n)), nil
+}
+
+func (l *level) UnmarshalText(text []byte) (err error) {
+	l.n, err = strconv.Atoi(strings.TrimPrefix(string(text), "L"))
+	return err
+}
+
+func TestStructTextMarshaler(t *testing.T) {
+	type row struct {
+		Level level `csv:"level"`
+	}
+	buf := &bytes.Buffer{}
+	csvmust.WriteAllFrom(csvmust.NewWriter(iomust.WriterOf(buf)), []row{{Level: level{n: 3}}})
+	if buf.String() != "level\nL3\n" {
+		t.Errorf("unexpected output %q", buf.String())
+	}
+	got := csvmust.ReadAllAs[row](csvmust.NewReader(iomust.ReaderOf(strings.NewReader(buf.String()))))
+	if len(got) != 1 || got[0].Level.n != 3 {
+		t.Errorf("unexpected round trip result %+v", got)
+	}
+}
+
+type failingWriter struct {
+	err      error
+	closeErr error
+	closed   bool
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	return 0, w.err
+}
+
+func (w *failingWriter) Close() error {
+	w.closed = true
+	return w.closeErr
+}
+
+func TestWriterErrors(t *testing.T) {
+	errDiskFull := errors.New("disk full")
+
+	t.Run("Flush panics on write error", func(t *testing.T) {
+		writer := csvmust.NewWriter(iomust.WriterOf(&failingWriter{err: errDiskFull}))
+		writer.Write([]string{"a", "b"})
+		defer func() {
+			err, _ := recover().(error)
+			if !errors.Is(err, errDiskFull) {
+				t.Errorf("expected panic with %v, got %v", errDiskFull, err)
+			}
+		}()
+		writer.Flush()
+	})
+
+	t.Run("Close flushes and closes", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		writer := csvmust.NewWriter(iomust.WriterOf(buf))
+		writer.Write([]string{"a", "b"})
+		writer.Close()
+		if buf.String() != "a,b\n" {
+			t.Errorf("expected %q, got %q", "a,b\n", buf.String())
+		}
+
+		closer := &failingWriter{}
+		csvmust.NewWriter(iomust.WriteCloserOf(closer)).Close()
+		if !closer.closed {
+			t.Error("Close did not close the underlying writer")
+		}
+	})
+
+	t.Run("Close panics on close error", func(t *testing.T) {
+		writer := csvmust.NewWriter(iomust.WriteCloserOf(&failingWriter{closeErr: errDiskFull}))
+		defer func() {
+			err, _ := recover().(error)
+			if !errors.Is(err, errDiskFull) {
+				t.Errorf("expected panic with %v, got %v", errDiskFull, err)
+			}
+		}()
+		writer.Close()
+	})
+
+	t.Run("Close panics on flush error", func(t *testing.T) {
+		closer := &failingWriter{err: errDiskFull}
+		writer := csvmust.NewWriter(iomust.WriteCloserOf(closer))
+		writer.Write([]string{"a"})
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("Close did not panic on flush error")
+			}
+		}()
+		writer.Close()
+	})
+}
+
+func TestWriteSeq(t *testing.T) {
+	buf := &bytes.Buffer{}
+	writer := csvmust.NewWriter(iomust.WriterOf(buf))
+	writer.WriteSeq(slices.Values([][]string{{"name", "age"}, {"Alice", "30"}}))
+	if expected := "name,age\nAlice,30\n"; buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+}
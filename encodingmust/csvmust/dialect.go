@@ -0,0 +1,245 @@
+package csvmust
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Dialect describes a variant of the CSV format.
+type Dialect struct {
+	// Comma is the field delimiter. The zero value means ','.
+	Comma rune
+	// Comment is the character starting comment lines when reading, or 0 if comments are not allowed.
+	Comment rune
+	// LazyQuotes allows quotes in unquoted fields and non-doubled quotes in quoted fields when reading.
+	LazyQuotes bool
+	// TrimLeadingSpace ignores leading white space in fields when reading.
+	TrimLeadingSpace bool
+	// UseCRLF terminates records with \r\n instead of \n when writing.
+	UseCRLF bool
+	// BOM writes a UTF-8 byte order mark at the beginning of the output when writing.
+	BOM bool
+}
+
+var (
+	// RFC4180 is the dialect of RFC 4180, which is the default of encoding/csv.
+	RFC4180 = Dialect{Comma: ','}
+	// TSV is the dialect of tab-separated values, which allows quotes in unquoted fields when reading.
+	// A field beginning with a quote is still read as a quoted field, and fields containing quotes, tabs or line breaks are quoted when writing.
+	TSV = Dialect{Comma: '\t', LazyQuotes: true}
+	// Excel is the dialect of CSV files produced and consumed by Microsoft Excel, written with a BOM and CRLF.
+	Excel = Dialect{Comma: ',', UseCRLF: true, BOM: true}
+	// Semicolon is the dialect of semicolon-separated values used in locales where the comma is the decimal separator.
+	Semicolon = Dialect{Comma: ';'}
+)
+
+// Option configures a Reader created by NewReader or a Writer created by NewWriter.
+type Option func(*options)
+
+type options struct {
+	dialect Dialect
+	sniff   bool
+}
+
+func newOptions(opts []Option) options {
+	o := options{dialect: RFC4180}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.dialect.Comma == 0 {
+		o.dialect.Comma = ','
+	}
+	return o
+}
+
+// WithDialect returns an Option that reads or writes the CSV format of d.
+func WithDialect(d Dialect) Option {
+	return func(o *options) {
+		o.dialect = d
+	}
+}
+
+// WithSniffing returns an Option that makes a Reader detect the field delimiter among ',', ';', '\t' and '|' from the first lines of the input.
+// The other settings of the dialect are kept. It is ignored by a Writer.
+func WithSniffing() Option {
+	return func(o *options) {
+		o.sniff = true
+	}
+}
+
+// sniffSize is the maximum number of bytes examined to detect the field delimiter.
+const sniffSize = 16 * 1024
+
+// sniffLines is the maximum number of records examined to detect the field delimiter.
+const sniffLines = 10
+
+// sniffComma returns the delimiter occurring the same number of times in every record of sample and most often.
+// If no delimiter occurs consistently, it returns the delimiter occurring most often in total.
+// The last record is ignored unless atEOF is true since it may be truncated.
+func sniffComma(sample []byte, atEOF bool) (rune, bool) {
+	candidates := []byte{',', ';', '\t', '|'}
+	var records [][]int
+	counts := make([]int, len(candidates))
+	inQuotes := false
+	for _, b := range sample {
+		switch {
+		case b == '"':
+			inQuotes = !inQuotes
+		case b == '\n' && !inQuotes:
+			records = append(records, counts)
+			counts = make([]int, len(candidates))
+		case !inQuotes:
+			if i := bytes.IndexByte(candidates, b); i >= 0 {
+				counts[i]++
+			}
+		}
+		if len(records) == sniffLines {
+			break
+		}
+	}
+	if atEOF && len(records) < sniffLines && !inQuotes && len(sample) > 0 && sample[len(sample)-1] != '\n' {
+		records = append(records, counts)
+	}
+	best, bestCount, bestConsistent := -1, 0, false
+	for i := range candidates {
+		total, consistent := 0, len(records) > 0
+		for _, record := range records {
+			total += record[i]
+			consistent = consistent && record[i] == records[0][i] && record[i] > 0
+		}
+		if total == 0 {
+			continue
+		}
+		if consistent && !bestConsistent || consistent == bestConsistent && total > bestCount {
+			best, bestCount, bestConsistent = i, total, consistent
+		}
+	}
+	if best < 0 {
+		return 0, false
+	}
+	return rune(candidates[best]), true
+}
+
+// bomReader strips a UTF-8 byte order mark at the beginning of the input,
+// and decodes the input into UTF-8 if it begins with a UTF-16 byte order mark.
+type bomReader struct {
+	reader  *bufio.Reader
+	decoded io.Reader
+}
+
+func newBOMReader(r io.Reader) *bomReader {
+	return &bomReader{reader: bufio.NewReader(r)}
+}
+
+func (r *bomReader) Read(p []byte) (int, error) {
+	if r.decoded == nil {
+		r.decoded = r.reader
+		prefix, _ := r.reader.Peek(3)
+		switch {
+		case bytes.HasPrefix(prefix, []byte{0xEF, 0xBB, 0xBF}):
+			_, _ = r.reader.Discard(3)
+		case bytes.HasPrefix(prefix, []byte{0xFF, 0xFE}):
+			_, _ = r.reader.Discard(2)
+			r.decoded = &utf16Reader{reader: r.reader, order: binary.LittleEndian}
+		case bytes.HasPrefix(prefix, []byte{0xFE, 0xFF}):
+			_, _ = r.reader.Discard(2)
+			r.decoded = &utf16Reader{reader: r.reader, order: binary.BigEndian}
+		}
+	}
+	return r.decoded.Read(p)
+}
+
+// utf16Reader decodes UTF-16 input into UTF-8. Invalid code units are decoded into U+FFFD.
+type utf16Reader struct {
+	reader  *bufio.Reader
+	order   binary.ByteOrder
+	pending []byte
+}
+
+// Read decodes code units into p until p is full. Once p holds some output, only the buffered input is decoded so as not to block on the source,
+// and a surrogate pair not yet buffered entirely is left for the next call, as are the bytes of a rune not fitting in p.
+func (r *utf16Reader) Read(p []byte) (int, error) {
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	for n < len(p) {
+		if n > 0 && !r.buffered() {
+			break
+		}
+		c, err := r.readRune()
+		if err != nil {
+			return n, err
+		}
+		var b [utf8.UTFMax]byte
+		size := utf8.EncodeRune(b[:], c)
+		m := copy(p[n:], b[:size])
+		n += m
+		if m < size {
+			r.pending = append(r.pending, b[m:size]...)
+			break
+		}
+	}
+	return n, nil
+}
+
+// buffered reports whether the next code point can be decoded from the buffered input without reading the source.
+func (r *utf16Reader) buffered() bool {
+	b, _ := r.reader.Peek(min(r.reader.Buffered(), 4))
+	if len(b) < 2 {
+		return false
+	}
+	return !utf16.IsSurrogate(rune(r.order.Uint16(b))) || len(b) == 4
+}
+
+// readRune reads a code point, decoding an unpaired surrogate into U+FFFD.
+func (r *utf16Reader) readRune() (rune, error) {
+	unit, err := r.readUnit()
+	if err != nil {
+		return 0, err
+	}
+	c := rune(unit)
+	if !utf16.IsSurrogate(c) {
+		return c, nil
+	}
+	next, err := r.reader.Peek(2)
+	if err != nil {
+		return utf8.RuneError, nil
+	}
+	d := utf16.DecodeRune(c, rune(r.order.Uint16(next)))
+	if d != utf8.RuneError {
+		_, _ = r.reader.Discard(2)
+	}
+	return d, nil
+}
+
+// readUnit reads a UTF-16 code unit. A trailing odd byte is decoded into U+FFFD.
+func (r *utf16Reader) readUnit() (uint16, error) {
+	var b [2]byte
+	n, err := io.ReadFull(r.reader, b[:])
+	switch {
+	case err == io.ErrUnexpectedEOF && n == 1:
+		return utf8.RuneError, nil
+	case err != nil:
+		return 0, err
+	}
+	return r.order.Uint16(b[:]), nil
+}
+
+// bomWriter writes a UTF-8 byte order mark before the first write to the underlying writer.
+type bomWriter struct {
+	writer  io.Writer
+	written bool
+}
+
+func (w *bomWriter) Write(p []byte) (int, error) {
+	if !w.written {
+		if _, err := w.writer.Write([]byte{0xEF, 0xBB, 0xBF}); err != nil {
+			return 0, err
+		}
+		w.written = true
+	}
+	return w.writer.Write(p)
+}
@@ -0,0 +1,161 @@
+package csvmust
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+)
+
+// ErrMissingColumn is reported when a column is not found in the header.
+var ErrMissingColumn = errors.New("missing column")
+
+// FieldError describes a failure of a field in CSV input with a header row.
+type FieldError struct {
+	// Row is the 1-based index of the record in the input, where the header row is 1.
+	Row int
+	// Column is the 1-based index of the field in the record, or 0 if the column is missing.
+	Column int
+	// Header is the name of the column.
+	Header string
+	Err    error
+}
+
+func (e *FieldError) Error() string {
+	if e.Column == 0 {
+		return fmt.Sprintf("csvmust: row %d: column %q: %v", e.Row, e.Header, e.Err)
+	}
+	return fmt.Sprintf("csvmust: row %d, column %d (%q): %v", e.Row, e.Column, e.Header, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// Record is a CSV record whose fields can be accessed by the names in the header row.
+type Record struct {
+	header *header
+	fields []string
+	row    int
+}
+
+// header is the header row of CSV input.
+type header struct {
+	names []string
+	index map[string]int
+}
+
+func newHeader(names []string) *header {
+	h := &header{names: append([]string(nil), names...), index: map[string]int{}}
+	for i, name := range h.names {
+		if _, ok := h.index[name]; !ok {
+			h.index[name] = i
+		}
+	}
+	return h
+}
+
+// Row returns the 1-based index of the record in the input, where the header row is 1.
+func (r Record) Row() int {
+	return r.row
+}
+
+// Header returns the names in the header row.
+func (r Record) Header() []string {
+	return append([]string(nil), r.header.names...)
+}
+
+// Fields returns the fields of the record in order.
+func (r Record) Fields() []string {
+	return append([]string(nil), r.fields...)
+}
+
+// Lookup returns the field of the named column and whether the column exists in the record.
+// If the header has duplicate names, the first column is used.
+func (r Record) Lookup(name string) (string, bool) {
+	i, ok := r.header.index[name]
+	if !ok || i >= len(r.fields) {
+		return "", false
+	}
+	return r.fields[i], true
+}
+
+// Get returns the field of the named column. Panics with a *FieldError if the column does not exist in the record.
+func (r Record) Get(name string) string {
+	field, ok := r.Lookup(name)
+	if !ok {
+		panic(&FieldError{Row: r.row, Header: name, Err: ErrMissingColumn})
+	}
+	return field
+}
+
+// Map returns the fields of the record keyed by the names in the header row.
+func (r Record) Map() map[string]string {
+	m := make(map[string]string, len(r.header.index))
+	for name, i := range r.header.index {
+		if i < len(r.fields) {
+			m[name] = r.fields[i]
+		}
+	}
+	return m
+}
+
+// RecordReader reads CSV input whose first record is a header row naming the columns.
+type RecordReader struct {
+	reader *Reader
+	header *header
+	row    int
+}
+
+// NewRecordReader returns a new RecordReader that reads from r and reads the header row.
+// Panics if an error occurs reading the header row or the input is empty.
+func NewRecordReader(r *Reader) *RecordReader {
+	names, err := r.Reader.Read()
+	if err == io.EOF {
+		panic(fmt.Errorf("csvmust: missing header row"))
+	}
+	if err != nil {
+		panic(r.locate(err))
+	}
+	return &RecordReader{reader: r, header: newHeader(names), row: 1}
+}
+
+// Header returns the names in the header row.
+func (r *RecordReader) Header() []string {
+	return append([]string(nil), r.header.names...)
+}
+
+// Read reads the next record. It returns false at the end of the input. Panics if an error occurs.
+func (r *RecordReader) Read() (record Record, ok bool) {
+	fields, err := r.reader.Reader.Read()
+	if err == io.EOF {
+		return Record{}, false
+	}
+	if err != nil {
+		panic(r.reader.locate(err))
+	}
+	r.row++
+	return Record{header: r.header, fields: append([]string(nil), fields...), row: r.row}, true
+}
+
+// ReadMap reads the next record as a map keyed by the names in the header row. It returns false at the end of the input.
+// Panics if an error occurs.
+func (r *RecordReader) ReadMap() (record map[string]string, ok bool) {
+	rec, ok := r.Read()
+	if !ok {
+		return nil, false
+	}
+	return rec.Map(), true
+}
+
+// All returns an iterator over the remaining records. Panics as Read during iteration.
+func (r *RecordReader) All() iter.Seq[Record] {
+	return func(yield func(Record) bool) {
+		for {
+			record, ok := r.Read()
+			if !ok || !yield(record) {
+				return
+			}
+		}
+	}
+}
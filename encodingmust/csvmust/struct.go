@@ -0,0 +1,228 @@
+package csvmust
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ReadAllAs reads the header row and all records from r and returns the records as values of the struct type T.
+//
+// Exported fields of T are mapped to the columns named by their csv tags, or by their names if untagged, and fields tagged csv:"-" are ignored.
+// A tag may have the options omitempty, which decodes an empty field into the zero value, and optional, which allows the column to be missing.
+// Strings, booleans, integers, floating-point numbers, time.Duration, encoding.TextUnmarshaler and pointers to them are supported,
+// and time.Time is parsed with the layout given by the layout tag or time.RFC3339 by default.
+// An empty field is decoded into nil for a pointer field.
+// Panics with a *FieldError if a required column is missing or a field cannot be converted, or panics if an error occurs reading the input.
+func ReadAllAs[T any](r *Reader) []T {
+	fields := structFieldsOf(reflect.TypeFor[T]())
+	records := NewRecordReader(r)
+	columns := make([]int, len(fields))
+	for i, f := range fields {
+		column, ok := records.header.index[f.name]
+		if !ok && !f.optional {
+			panic(&FieldError{Row: 1, Header: f.name, Err: ErrMissingColumn})
+		}
+		if !ok {
+			column = -1
+		}
+		columns[i] = column
+	}
+	var values []T
+	for record := range records.All() {
+		var v T
+		rv := reflect.ValueOf(&v).Elem()
+		for i, f := range fields {
+			column := columns[i]
+			if column < 0 || column >= len(record.fields) {
+				continue
+			}
+			if err := f.parse(rv.Field(f.index), record.fields[column]); err != nil {
+				panic(&FieldError{Row: record.row, Column: column + 1, Header: f.name, Err: err})
+			}
+		}
+		values = append(values, v)
+	}
+	return values
+}
+
+// WriteAllFrom writes a header row and the values of the struct type T as records to w, and flushes w.
+// Fields are mapped to columns and converted as ReadAllAs, and zero values of fields tagged omitempty are written as empty fields.
+// Panics with a *FieldError if a field cannot be converted, or panics if an error occurs writing the output.
+func WriteAllFrom[T any](w *Writer, values []T) {
+	fields := structFieldsOf(reflect.TypeFor[T]())
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		names[i] = f.name
+	}
+	records := [][]string{names}
+	for row := range values {
+		// The value is addressable so that MarshalText with a pointer receiver is used.
+		rv := reflect.ValueOf(&values[row]).Elem()
+		record := make([]string, len(fields))
+		for i, f := range fields {
+			s, err := f.format(rv.Field(f.index))
+			if err != nil {
+				panic(&FieldError{Row: row + 2, Column: i + 1, Header: f.name, Err: err})
+			}
+			record[i] = s
+		}
+		records = append(records, record)
+	}
+	w.WriteAll(records)
+}
+
+// structField is a field of a struct mapped to a CSV column.
+type structField struct {
+	index     int
+	name      string
+	layout    string
+	omitempty bool
+	optional  bool
+}
+
+func structFieldsOf(t reflect.Type) []structField {
+	if t.Kind() != reflect.Struct {
+		panic(fmt.Errorf("csvmust: %v is not a struct type", t))
+	}
+	var fields []structField
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("csv")
+		if !sf.IsExported() || tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = sf.Name
+		}
+		f := structField{index: i, name: name, layout: sf.Tag.Get("layout")}
+		if f.layout == "" {
+			f.layout = time.RFC3339
+		}
+		for option := range strings.SplitSeq(options, ",") {
+			switch option {
+			case "omitempty":
+				f.omitempty = true
+			case "optional":
+				f.optional = true
+			}
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+var (
+	timeType            = reflect.TypeFor[time.Time]()
+	durationType        = reflect.TypeFor[time.Duration]()
+	textMarshalerType   = reflect.TypeFor[encoding.TextMarshaler]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+// parse converts s and stores the result in v.
+func (f structField) parse(v reflect.Value, s string) error {
+	if v.Kind() == reflect.Pointer {
+		if s == "" {
+			v.SetZero()
+			return nil
+		}
+		v.Set(reflect.New(v.Type().Elem()))
+		v = v.Elem()
+	}
+	if s == "" && f.omitempty {
+		v.SetZero()
+		return nil
+	}
+	switch {
+	case v.Type() == timeType:
+		t, err := time.Parse(f.layout, s)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	case v.Type() == durationType:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	case reflect.PointerTo(v.Type()).Implements(textUnmarshalerType):
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		x, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(x)
+	default:
+		return fmt.Errorf("unsupported type %v", v.Type())
+	}
+	return nil
+}
+
+// format converts v into a field.
+func (f structField) format(v reflect.Value) (string, error) {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return "", nil
+		}
+		v = v.Elem()
+	}
+	if f.omitempty && v.IsZero() {
+		return "", nil
+	}
+	switch {
+	case v.Type() == timeType:
+		return v.Interface().(time.Time).Format(f.layout), nil
+	case v.Type() == durationType:
+		return time.Duration(v.Int()).String(), nil
+	case v.Type().Implements(textMarshalerType):
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), err
+	case v.CanAddr() && v.Addr().Type().Implements(textMarshalerType):
+		text, err := v.Addr().Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), err
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()), nil
+	default:
+		return "", fmt.Errorf("unsupported type %v", v.Type())
+	}
+}
@@ -0,0 +1,202 @@
+// Package encodingmust provides utilities shared by the wrappers for the encoding packages.
+package encodingmust
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// DecodeError describes an error occurred decoding input, with its location and an excerpt of the input.
+type DecodeError struct {
+	// Offset is the byte offset of the error in the input, or -1 if unknown.
+	Offset int64
+	// Line is the 1-based line number of the error, or 0 if unknown.
+	Line int
+	// Column is the 1-based byte index of the error in the line, or 0 if unknown.
+	Column int
+	// Excerpt is the line of the error followed by a line with a caret pointing at the column, or empty if unavailable.
+	Excerpt string
+	Err     error
+}
+
+func (e *DecodeError) Error() string {
+	var b strings.Builder
+	switch {
+	case e.Line > 0 && e.Column > 0:
+		fmt.Fprintf(&b, "line %d, column %d: %v", e.Line, e.Column, e.Err)
+	case e.Line > 0:
+		fmt.Fprintf(&b, "line %d: %v", e.Line, e.Err)
+	case e.Offset >= 0:
+		fmt.Fprintf(&b, "offset %d: %v", e.Offset, e.Err)
+	default:
+		b.WriteString(e.Err.Error())
+	}
+	if e.Excerpt != "" {
+		for line := range strings.Lines(e.Excerpt) {
+			b.WriteString("\n\t" + strings.TrimSuffix(line, "\n"))
+		}
+	}
+	return b.String()
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// NewDecodeError returns a DecodeError for err occurred at the byte offset in input.
+func NewDecodeError(input []byte, offset int64, err error) *DecodeError {
+	return locate(input, 0, 1, true, offset, err)
+}
+
+// NewDecodeErrorAt returns a DecodeError for err occurred at the 1-based line and the 1-based byte column in input.
+// If column is 0, the column is unknown and the excerpt has only the line.
+func NewDecodeErrorAt(input []byte, line, column int, err error) *DecodeError {
+	r := &PositionReader{window: input, windowLine: 1, atLineStart: true, offset: int64(len(input))}
+	return r.DecodeErrorAt(line, column, err)
+}
+
+// maxExcerptWidth is the maximum number of bytes of the input line shown in an excerpt.
+const maxExcerptWidth = 72
+
+// locate returns a DecodeError for err occurred at offset, using window, which is the part of the input starting at windowStart on the line windowLine.
+// If atLineStart is false, the window starts in the middle of the line.
+func locate(window []byte, windowStart int64, windowLine int, atLineStart bool, offset int64, err error) *DecodeError {
+	e := &DecodeError{Offset: offset, Err: err}
+	if offset < windowStart || offset > windowStart+int64(len(window)) {
+		return e
+	}
+	prefix := window[:offset-windowStart]
+	e.Line = windowLine + bytes.Count(prefix, []byte("\n"))
+	lineStart := bytes.LastIndexByte(prefix, '\n') + 1
+	if lineStart == 0 && !atLineStart {
+		return e
+	}
+	lineEnd := len(window)
+	if i := bytes.IndexByte(window[lineStart:], '\n'); i >= 0 {
+		lineEnd = lineStart + i
+	}
+	col := len(prefix) - lineStart
+	e.Column = col + 1
+	e.Excerpt = excerpt(bytes.TrimSuffix(window[lineStart:lineEnd], []byte("\r")), col)
+	return e
+}
+
+// excerpt returns line followed by a line with a caret at the byte index col, truncating line around col if it is long.
+func excerpt(line []byte, col int) string {
+	col = min(col, len(line))
+	start, end := 0, len(line)
+	if end-start > maxExcerptWidth {
+		start = max(0, col-maxExcerptWidth/2)
+		end = min(len(line), start+maxExcerptWidth)
+		start = max(0, end-maxExcerptWidth)
+	}
+	// Align the bounds to rune boundaries.
+	for start > 0 && !utf8.RuneStart(line[start]) {
+		start--
+	}
+	for end < len(line) && !utf8.RuneStart(line[end]) {
+		end++
+	}
+	var text, caret strings.Builder
+	if start > 0 {
+		text.WriteString("...")
+		caret.WriteString("   ")
+	}
+	text.Write(line[start:end])
+	if end < len(line) {
+		text.WriteString("...")
+	}
+	for _, r := range string(line[start:col]) {
+		if r == '\t' {
+			caret.WriteRune('\t')
+		} else {
+			caret.WriteRune(' ')
+		}
+	}
+	caret.WriteString("^")
+	return text.String() + "\n" + caret.String()
+}
+
+// maxWindow is the number of bytes PositionReader retains to locate errors.
+const maxWindow = 64 * 1024
+
+// PositionReader is an io.Reader that reads from an underlying reader and retains the recently read input,
+// so that decoders reading from it can locate errors by byte offsets or lines.
+type PositionReader struct {
+	reader      io.Reader
+	offset      int64
+	window      []byte
+	windowStart int64
+	windowLine  int
+	atLineStart bool
+}
+
+var _ io.Reader = (*PositionReader)(nil)
+
+// NewPositionReader returns a new PositionReader that reads from r.
+func NewPositionReader(r io.Reader) *PositionReader {
+	return &PositionReader{reader: r, windowLine: 1, atLineStart: true}
+}
+
+// Read reads from the underlying reader.
+func (r *PositionReader) Read(p []byte) (n int, err error) {
+	n, err = r.reader.Read(p)
+	r.offset += int64(n)
+	r.window = append(r.window, p[:n]...)
+	if len(r.window) > 2*maxWindow {
+		cut := len(r.window) - maxWindow
+		if i := bytes.LastIndexByte(r.window[:cut], '\n'); i >= 0 {
+			cut, r.atLineStart = i+1, true
+		} else {
+			r.atLineStart = false
+		}
+		r.windowLine += bytes.Count(r.window[:cut], []byte("\n"))
+		r.windowStart += int64(cut)
+		r.window = append([]byte(nil), r.window[cut:]...)
+	}
+	return n, err
+}
+
+// Offset returns the number of bytes read so far.
+func (r *PositionReader) Offset() int64 {
+	return r.offset
+}
+
+// Input returns the input between the byte offsets start and end if it is still retained, or nil otherwise.
+func (r *PositionReader) Input(start, end int64) []byte {
+	if start < r.windowStart || end > r.offset || start > end {
+		return nil
+	}
+	return r.window[start-r.windowStart : end-r.windowStart]
+}
+
+// DecodeError returns a DecodeError for err occurred at the byte offset in the input.
+func (r *PositionReader) DecodeError(offset int64, err error) *DecodeError {
+	return locate(r.window, r.windowStart, r.windowLine, r.atLineStart, offset, err)
+}
+
+// DecodeErrorAt returns a DecodeError for err occurred at the 1-based line and the 1-based byte column in the input.
+// If column is 0, the column is unknown and the excerpt has only the line.
+func (r *PositionReader) DecodeErrorAt(line, column int, err error) *DecodeError {
+	if column <= 0 {
+		e := r.DecodeErrorAt(line, 1, err)
+		e.Column = 0
+		e.Excerpt, _, _ = strings.Cut(e.Excerpt, "\n")
+		return e
+	}
+	if line < r.windowLine || line == r.windowLine && !r.atLineStart {
+		return &DecodeError{Offset: -1, Line: line, Column: column, Err: err}
+	}
+	lineStart := 0
+	for l := r.windowLine; l < line; l++ {
+		i := bytes.IndexByte(r.window[lineStart:], '\n')
+		if i < 0 {
+			return &DecodeError{Offset: -1, Line: line, Column: column, Err: err}
+		}
+		lineStart += i + 1
+	}
+	return r.DecodeError(r.windowStart+int64(lineStart+max(column-1, 0)), err)
+}
@@ -0,0 +1,121 @@
+package encodingmust_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/Jumpaku/go-mustd/encodingmust"
+)
+
+func TestNewDecodeError(t *testing.T) {
+	errTest := errors.New("test error")
+
+	t.Run("location and excerpt", func(t *testing.T) {
+		input := []byte("first\nsecond line\r\nthird")
+		err := encodingmust.NewDecodeError(input, 13, errTest)
+		if err.Offset != 13 || err.Line != 2 || err.Column != 8 {
+			t.Errorf("expected offset 13, line 2, column 8, got offset %d, line %d, column %d", err.Offset, err.Line, err.Column)
+		}
+		if err.Excerpt != "second line\n       ^" {
+			t.Errorf("unexpected excerpt %q", err.Excerpt)
+		}
+		expected := "line 2, column 8: test error\n\tsecond line\n\t       ^"
+		if err.Error() != expected {
+			t.Errorf("expected %q, got %q", expected, err.Error())
+		}
+		if !errors.Is(err, errTest) {
+			t.Error("DecodeError does not unwrap to the underlying error")
+		}
+	})
+
+	t.Run("long line is truncated around the column", func(t *testing.T) {
+		input := []byte(strings.Repeat("a", 100) + "X" + strings.Repeat("b", 100))
+		err := encodingmust.NewDecodeError(input, 100, errTest)
+		text, caret, _ := strings.Cut(err.Excerpt, "\n")
+		if !strings.HasPrefix(text, "...") || !strings.HasSuffix(text, "...") {
+			t.Errorf("expected truncated excerpt, got %q", text)
+		}
+		if text[len(caret)-1] != 'X' {
+			t.Errorf("caret does not point at the offending byte: %q", err.Excerpt)
+		}
+	})
+
+	t.Run("offset out of range", func(t *testing.T) {
+		err := encodingmust.NewDecodeError([]byte("abc"), 10, errTest)
+		if err.Line != 0 || err.Excerpt != "" {
+			t.Errorf("expected unknown location, got line %d, excerpt %q", err.Line, err.Excerpt)
+		}
+		if err.Error() != "offset 10: test error" {
+			t.Errorf("unexpected message %q", err.Error())
+		}
+	})
+}
+
+func TestNewDecodeErrorAt(t *testing.T) {
+	errTest := errors.New("test error")
+	input := []byte("first\nsecond line\nthird")
+
+	t.Run("with column", func(t *testing.T) {
+		err := encodingmust.NewDecodeErrorAt(input, 2, 8, errTest)
+		if err.Offset != 13 || err.Excerpt != "second line\n       ^" {
+			t.Errorf("unexpected location: %+v", err)
+		}
+	})
+
+	t.Run("unknown column", func(t *testing.T) {
+		err := encodingmust.NewDecodeErrorAt(input, 3, 0, errTest)
+		if err.Line != 3 || err.Column != 0 || err.Excerpt != "third" {
+			t.Errorf("unexpected location: %+v", err)
+		}
+		if expected := "line 3: test error\n\tthird"; err.Error() != expected {
+			t.Errorf("expected %q, got %q", expected, err.Error())
+		}
+	})
+}
+
+func TestPositionReader(t *testing.T) {
+	errTest := errors.New("test error")
+
+	t.Run("DecodeError and DecodeErrorAt", func(t *testing.T) {
+		r := encodingmust.NewPositionReader(strings.NewReader("ab\ncd\nef"))
+		if _, err := io.ReadAll(r); err != nil {
+			t.Fatal(err)
+		}
+		if r.Offset() != 8 {
+			t.Errorf("expected offset 8, got %d", r.Offset())
+		}
+		byOffset := r.DecodeError(4, errTest)
+		byLine := r.DecodeErrorAt(2, 2, errTest)
+		if byOffset.Line != 2 || byOffset.Column != 2 || byOffset.Excerpt != "cd\n ^" {
+			t.Errorf("unexpected location by offset: %+v", byOffset)
+		}
+		if byLine.Offset != 4 || byLine.Excerpt != byOffset.Excerpt {
+			t.Errorf("unexpected location by line: %+v", byLine)
+		}
+		if got := string(r.Input(3, 5)); got != "cd" {
+			t.Errorf("expected input %q, got %q", "cd", got)
+		}
+	})
+
+	t.Run("long input", func(t *testing.T) {
+		line := strings.Repeat("x", 99) + "\n"
+		input := strings.Repeat(line, 5000) + "bad\n"
+		r := encodingmust.NewPositionReader(strings.NewReader(input))
+		if _, err := io.ReadAll(r); err != nil {
+			t.Fatal(err)
+		}
+		err := r.DecodeError(int64(len(input)-2), errTest)
+		if err.Line != 5001 || err.Column != 3 || err.Excerpt != "bad\n  ^" {
+			t.Errorf("unexpected location: %+v", err)
+		}
+		old := r.DecodeError(10, errTest)
+		if old.Line != 0 || old.Offset != 10 {
+			t.Errorf("expected unknown line for discarded input, got %+v", old)
+		}
+		if r.Input(10, 20) != nil {
+			t.Error("expected nil for discarded input")
+		}
+	})
+}
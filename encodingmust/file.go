@@ -0,0 +1,49 @@
+package encodingmust
+
+import (
+	"errors"
+	"io/fs"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/Jumpaku/go-mustd"
+)
+
+// WriteFileAtomic writes data to the named file, creating it if necessary. Panics if an error occurs.
+// The file is replaced atomically by writing a temporary file in the same directory and renaming it.
+// The file is created with perm before umask as os.WriteFile.
+func WriteFileAtomic(name string, data []byte, perm os.FileMode) {
+	mustd.Must0(writeFileAtomic(name, data, perm))
+}
+
+func writeFileAtomic(name string, data []byte, perm os.FileMode) (err error) {
+	var f *os.File
+	for {
+		tmp := filepath.Join(filepath.Dir(name), "."+filepath.Base(name)+"."+strconv.FormatUint(rand.Uint64(), 36)+".tmp")
+		f, err = os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+		if !errors.Is(err, fs.ErrExist) {
+			break
+		}
+	}
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+	if _, err = f.Write(data); err != nil {
+		return err
+	}
+	if err = f.Sync(); err != nil {
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), name)
+}
@@ -0,0 +1,95 @@
+// Package gobmust provides wrappers for the encoding/gob package with panicking error handling.
+package gobmust
+
+import (
+	"bytes"
+	"encoding/gob"
+	"os"
+	"reflect"
+
+	"github.com/Jumpaku/go-mustd"
+	"github.com/Jumpaku/go-mustd/encodingmust"
+	"github.com/Jumpaku/go-mustd/iomust"
+)
+
+// Marshal returns the gob encoding of v as a self-contained stream. Panics if an error occurs.
+func Marshal(v any) []byte {
+	var buf bytes.Buffer
+	mustd.Must0(gob.NewEncoder(&buf).Encode(v))
+	return buf.Bytes()
+}
+
+// UnmarshalAs decodes the gob-encoded data produced by Marshal and returns the result as a value of type T. Panics if an error occurs.
+func UnmarshalAs[T any](data []byte) T {
+	var v T
+	mustd.Must0(gob.NewDecoder(bytes.NewReader(data)).Decode(&v))
+	return v
+}
+
+// EncodeFile writes the gob encoding of v to the named file, creating it if necessary. Panics if an error occurs.
+// The file is replaced atomically by writing a temporary file in the same directory and renaming it.
+func EncodeFile(name string, v any, perm os.FileMode) {
+	encodingmust.WriteFileAtomic(name, Marshal(v), perm)
+}
+
+// DecodeFileAs reads the named file written by EncodeFile and returns its content as a value of type T. Panics if an error occurs.
+func DecodeFileAs[T any](name string) T {
+	return UnmarshalAs[T](mustd.Must1(os.ReadFile(name)))
+}
+
+// Register records the concrete type T under its default name so that values of T can be sent as implementations of interface types.
+func Register[T any]() {
+	gob.Register(*new(T))
+}
+
+// RegisterName is like Register but uses name instead of the default name of T.
+func RegisterName[T any](name string) {
+	gob.RegisterName(name, *new(T))
+}
+
+// Decoder wraps gob.Decoder and provides panicking error handling.
+type Decoder struct {
+	decoder *gob.Decoder
+}
+
+// NewDecoder returns a new decoder that reads from r.
+func NewDecoder(r iomust.Reader) *Decoder {
+	return &Decoder{decoder: gob.NewDecoder(r.Reader())}
+}
+
+// Decode reads the next value from the input stream and stores it in e. Panics if an error occurs.
+func (dec *Decoder) Decode(e any) {
+	mustd.Must0(dec.decoder.Decode(e))
+}
+
+// DecodeValue reads the next value from the input stream and stores it in v. Panics if an error occurs.
+func (dec *Decoder) DecodeValue(v reflect.Value) {
+	mustd.Must0(dec.decoder.DecodeValue(v))
+}
+
+// DecodeAs reads the next value from the input stream of dec and returns it as a value of type T. Panics if an error occurs.
+func DecodeAs[T any](dec *Decoder) T {
+	var v T
+	dec.Decode(&v)
+	return v
+}
+
+// Encoder wraps gob.Encoder and provides panicking error handling.
+type Encoder struct {
+	encoder *gob.Encoder
+}
+
+// NewEncoder returns a new encoder that writes to w.
+func NewEncoder(w iomust.Writer) *Encoder {
+	return &Encoder{encoder: gob.NewEncoder(w.Writer())}
+}
+
+// Encode transmits the data item represented by e. Panics if an error occurs.
+func (enc *Encoder) Encode(e any) {
+	mustd.Must0(enc.encoder.Encode(e))
+}
+
+// EncodeValue transmits the data item represented by v. Panics if an error occurs.
+func (enc *Encoder) EncodeValue(v reflect.Value) {
+	mustd.Must0(enc.encoder.EncodeValue(v))
+}
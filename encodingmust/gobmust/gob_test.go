@@ -0,0 +1,99 @@
+package gobmust_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Jumpaku/go-mustd/encodingmust/gobmust"
+	"github.com/Jumpaku/go-mustd/iomust"
+)
+
+type shape interface {
+	Area() float64
+}
+
+type rect struct {
+	W, H float64
+}
+
+func (r rect) Area() float64 { return r.W * r.H }
+
+type circle struct {
+	R float64
+}
+
+func (c circle) Area() float64 { return 3 * c.R * c.R }
+
+type drawing struct {
+	Name   string
+	Shapes []shape
+}
+
+func init() {
+	gobmust.Register[rect]()
+	gobmust.RegisterName[circle]("gobmust_test.circle")
+}
+
+func TestEncoderDecoder(t *testing.T) {
+	t.Run("stream of values", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		enc := gobmust.NewEncoder(iomust.WriterOf(buf))
+		enc.Encode(drawing{Name: "a", Shapes: []shape{rect{W: 1, H: 2}, circle{R: 1}}})
+		enc.Encode(drawing{Name: "b"})
+		enc.EncodeValue(reflect.ValueOf(42))
+
+		dec := gobmust.NewDecoder(iomust.ReaderOf(buf))
+		first := gobmust.DecodeAs[drawing](dec)
+		if first.Name != "a" || !reflect.DeepEqual(first.Shapes, []shape{rect{W: 1, H: 2}, circle{R: 1}}) {
+			t.Errorf("unexpected first value %+v", first)
+		}
+		var second drawing
+		dec.Decode(&second)
+		if second.Name != "b" {
+			t.Errorf("unexpected second value %+v", second)
+		}
+		var n int
+		dec.DecodeValue(reflect.ValueOf(&n))
+		if n != 42 {
+			t.Errorf("expected 42, got %d", n)
+		}
+	})
+
+	t.Run("Decode type mismatch panics", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("Decode did not panic")
+			}
+		}()
+		data := gobmust.Marshal("text")
+		gobmust.UnmarshalAs[int](data)
+	})
+
+	t.Run("Encode unregistered type panics", func(t *testing.T) {
+		type triangle struct{ rect }
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("Encode did not panic")
+			}
+		}()
+		gobmust.Marshal(drawing{Shapes: []shape{triangle{}}})
+	})
+}
+
+func TestEncodeDecodeFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "drawing.gob")
+	want := drawing{Name: "file", Shapes: []shape{circle{R: 2}}}
+	gobmust.EncodeFile(name, want, 0o644)
+	if got := gobmust.DecodeFileAs[drawing](name); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("DecodeFileAs did not panic on missing file")
+		}
+	}()
+	gobmust.DecodeFileAs[drawing](filepath.Join(t.TempDir(), "missing.gob"))
+}
@@ -0,0 +1,69 @@
+// Package hexmust provides wrappers for the encoding/hex package with panicking error handling.
+package hexmust
+
+import (
+	"encoding/hex"
+
+	"github.com/Jumpaku/go-mustd"
+	"github.com/Jumpaku/go-mustd/iomust"
+)
+
+// AppendDecode appends the hexadecimally decoded src to dst. Panics if an error occurs.
+func AppendDecode(dst, src []byte) []byte {
+	return mustd.Must1(hex.AppendDecode(dst, src))
+}
+
+// AppendEncode appends the hexadecimally encoded src to dst.
+func AppendEncode(dst, src []byte) []byte {
+	return hex.AppendEncode(dst, src)
+}
+
+// Decode decodes src into dst. Panics if an error occurs.
+func Decode(dst, src []byte) (n int) {
+	return mustd.Must1(hex.Decode(dst, src))
+}
+
+// DecodeString returns the bytes represented by the hexadecimal string s. Panics if an error occurs.
+func DecodeString(s string) []byte {
+	return mustd.Must1(hex.DecodeString(s))
+}
+
+// DecodedLen returns the length of a decoding of x source bytes.
+func DecodedLen(x int) int {
+	return hex.DecodedLen(x)
+}
+
+// Dump returns a string that contains a hex dump of data.
+func Dump(data []byte) string {
+	return hex.Dump(data)
+}
+
+// Dumper returns a WriteCloser that writes a hex dump of all written data to w. Close writes the remaining dump.
+func Dumper(w iomust.Writer) iomust.WriteCloser {
+	return iomust.WriteCloserOf(hex.Dumper(w.Writer()))
+}
+
+// Encode encodes src into dst.
+func Encode(dst, src []byte) int {
+	return hex.Encode(dst, src)
+}
+
+// EncodeToString returns the hexadecimal encoding of src.
+func EncodeToString(src []byte) string {
+	return hex.EncodeToString(src)
+}
+
+// EncodedLen returns the length of an encoding of n source bytes.
+func EncodedLen(n int) int {
+	return hex.EncodedLen(n)
+}
+
+// NewDecoder returns a new hexadecimal stream decoder.
+func NewDecoder(r iomust.Reader) iomust.Reader {
+	return iomust.ReaderOf(hex.NewDecoder(r.Reader()))
+}
+
+// NewEncoder returns a new hexadecimal stream encoder.
+func NewEncoder(w iomust.Writer) iomust.Writer {
+	return iomust.WriterOf(hex.NewEncoder(w.Writer()))
+}
@@ -0,0 +1,91 @@
+package hexmust_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/Jumpaku/go-mustd/encodingmust/hexmust"
+	"github.com/Jumpaku/go-mustd/iomust"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	t.Run("EncodeToString and DecodeString", func(t *testing.T) {
+		encoded := hexmust.EncodeToString([]byte("hello"))
+		if encoded != "68656c6c6f" {
+			t.Errorf("expected 68656c6c6f, got %s", encoded)
+		}
+		if decoded := hexmust.DecodeString(encoded); string(decoded) != "hello" {
+			t.Errorf("expected hello, got %s", decoded)
+		}
+	})
+
+	t.Run("DecodeString invalid data panics", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("DecodeString did not panic with invalid data")
+			}
+		}()
+		hexmust.DecodeString("zz")
+	})
+
+	t.Run("Encode and Decode", func(t *testing.T) {
+		data := []byte{0xde, 0xad, 0xbe, 0xef}
+		dst := make([]byte, hexmust.EncodedLen(len(data)))
+		hexmust.Encode(dst, data)
+		decoded := make([]byte, hexmust.DecodedLen(len(dst)))
+		n := hexmust.Decode(decoded, dst)
+		if !bytes.Equal(decoded[:n], data) {
+			t.Errorf("expected %x, got %x", data, decoded[:n])
+		}
+	})
+
+	t.Run("AppendEncode and AppendDecode", func(t *testing.T) {
+		encoded := hexmust.AppendEncode([]byte("0x"), []byte{0x01, 0xff})
+		if string(encoded) != "0x01ff" {
+			t.Errorf("expected 0x01ff, got %s", encoded)
+		}
+		decoded := hexmust.AppendDecode([]byte{0x00}, encoded[2:])
+		if !bytes.Equal(decoded, []byte{0x00, 0x01, 0xff}) {
+			t.Errorf("unexpected decoded data %x", decoded)
+		}
+	})
+}
+
+func TestStream(t *testing.T) {
+	t.Run("NewEncoder and NewDecoder", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		hexmust.NewEncoder(iomust.WriterOf(buf)).Write([]byte("hello"))
+		dec := hexmust.NewDecoder(iomust.ReaderOf(buf))
+		decoded, err := io.ReadAll(dec.Reader())
+		if err != nil || string(decoded) != "hello" {
+			t.Errorf("expected hello, got %s (%v)", decoded, err)
+		}
+	})
+
+	t.Run("NewDecoder invalid data panics", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("Read did not panic with invalid data")
+			}
+		}()
+		dec := hexmust.NewDecoder(iomust.ReaderOf(strings.NewReader("0g")))
+		dec.Read(make([]byte, 8))
+	})
+}
+
+func TestDumper(t *testing.T) {
+	data := []byte("firmware header\x00\x01")
+	buf := &bytes.Buffer{}
+	dumper := hexmust.Dumper(iomust.WriterOf(buf))
+	dumper.Write(data[:5])
+	dumper.Write(data[5:])
+	dumper.Close()
+	if buf.String() != hexmust.Dump(data) {
+		t.Errorf("expected %q, got %q", hexmust.Dump(data), buf.String())
+	}
+	if !strings.HasPrefix(buf.String(), "00000000  66 69 72 6d") {
+		t.Errorf("unexpected dump %q", buf.String())
+	}
+}
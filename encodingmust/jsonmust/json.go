@@ -4,11 +4,93 @@ package jsonmust
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
+	"io"
+	"os"
 
 	"github.com/Jumpaku/go-mustd"
+	"github.com/Jumpaku/go-mustd/encodingmust"
 	"github.com/Jumpaku/go-mustd/iomust"
 )
 
+// DecodeOption configures decoding by UnmarshalAs, ReadFileAs and DecodeAs.
+type DecodeOption func(*decodeOptions)
+
+type decodeOptions struct {
+	strict bool
+	// useNumber is set by DecodeAs according to Decoder.UseNumber.
+	useNumber bool
+}
+
+// Strict returns a DecodeOption that rejects object keys which do not match any non-ignored, exported fields of the destination struct
+// as Decoder.DisallowUnknownFields, and data following the decoded value.
+func Strict() DecodeOption {
+	return func(o *decodeOptions) {
+		o.strict = true
+	}
+}
+
+func newDecodeOptions(opts []DecodeOption) decodeOptions {
+	var o decodeOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// unmarshal parses the JSON-encoded data into v according to o.
+func unmarshal(data []byte, v any, o decodeOptions) error {
+	if !o.strict && !o.useNumber {
+		return json.Unmarshal(data, v)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if o.strict {
+		dec.DisallowUnknownFields()
+	}
+	if o.useNumber {
+		dec.UseNumber()
+	}
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		if err == nil {
+			err = errors.New("json: unexpected data after top-level value")
+		}
+		return err
+	}
+	return nil
+}
+
+// unmarshalLocated is like unmarshal but returns syntax and type errors as *encodingmust.DecodeError locating them in data.
+func unmarshalLocated(data []byte, v any, o decodeOptions) error {
+	err := unmarshal(data, v, o)
+	valueStart := int64(0)
+	if o.strict {
+		// Offsets of type errors reported by json.Decoder are relative to the start of the value.
+		valueStart = int64(len(data) - len(bytes.TrimLeft(data, " \t\r\n")))
+	}
+	return locateError(err, valueStart, func(offset int64, err error) error {
+		return encodingmust.NewDecodeError(data, offset, err)
+	})
+}
+
+// locateError returns the result of locate applied to err and its offset if err is a syntax or type error of encoding/json, and otherwise err itself.
+// Offsets of type errors are relative to valueStart.
+func locateError(err error, valueStart int64, locate func(offset int64, err error) error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		// The offset of a syntax error is just after the offending byte.
+		return locate(max(syntaxErr.Offset-1, 0), err)
+	case errors.As(err, &typeErr):
+		// The offset of a type error is just after the offending value.
+		return locate(valueStart+max(typeErr.Offset-1, 0), err)
+	}
+	return err
+}
+
 // Compact appends the JSON-encoded src to dst, eliminating insignificant whitespace. Panics if an error occurs.
 func Compact(dst *bytes.Buffer, src []byte) {
 	mustd.Must0(json.Compact(dst, src))
@@ -30,18 +112,60 @@ func MarshalIndent(v any, prefix, indent string) []byte {
 }
 
 // Unmarshal parses the JSON-encoded data and stores the result in v. Panics if an error occurs.
+// Syntax and type errors are reported as *encodingmust.DecodeError with the line, the column and an excerpt of data.
 func Unmarshal(data []byte, v any) {
-	mustd.Must0(json.Unmarshal(data, v))
+	mustd.Must0(unmarshalLocated(data, v, decodeOptions{}))
+}
+
+// UnmarshalAs parses the JSON-encoded data and returns the result as a value of type T. Panics if an error occurs.
+// Syntax and type errors are reported as *encodingmust.DecodeError as Unmarshal.
+func UnmarshalAs[T any](data []byte, opts ...DecodeOption) T {
+	var v T
+	mustd.Must0(unmarshalLocated(data, &v, newDecodeOptions(opts)))
+	return v
+}
+
+// ReadFileAs reads the named JSON file and returns its content as a value of type T. Panics if an error occurs.
+func ReadFileAs[T any](name string, opts ...DecodeOption) T {
+	return UnmarshalAs[T](mustd.Must1(os.ReadFile(name)), opts...)
+}
+
+// WriteFile writes the indented JSON encoding of v to the named file, creating it if necessary. Panics if an error occurs.
+// The file is replaced atomically by writing a temporary file in the same directory and renaming it.
+func WriteFile(name string, v any, perm os.FileMode) {
+	data := append(MarshalIndent(v, "", "  "), '\n')
+	encodingmust.WriteFileAtomic(name, data, perm)
 }
 
 // Decoder wraps json.Decoder and provides panicking error handling.
+// Syntax and type errors are reported as *encodingmust.DecodeError with the line, the column and an excerpt of the input.
 type Decoder struct {
-	decoder *json.Decoder
+	decoder  *json.Decoder
+	position *encodingmust.PositionReader
+	// useNumber is set by UseNumber to be respected by DecodeAs.
+	useNumber bool
 }
 
 // NewDecoder returns a new decoder that reads from r.
 func NewDecoder(r iomust.Reader) *Decoder {
-	return &Decoder{decoder: json.NewDecoder(r.Reader())}
+	position := encodingmust.NewPositionReader(r.Reader())
+	return &Decoder{decoder: json.NewDecoder(position), position: position}
+}
+
+// locate returns err with its location in the input. Offsets of type errors are relative to valueStart.
+func (dec *Decoder) locate(err error, valueStart int64) error {
+	if err == io.ErrUnexpectedEOF {
+		return dec.position.DecodeError(dec.position.Offset(), err)
+	}
+	return locateError(err, valueStart, func(offset int64, err error) error {
+		return dec.position.DecodeError(offset, err)
+	})
+}
+
+// decodeRaw reads the next JSON-encoded value and returns it with its offset. Panics if an error occurs.
+func (dec *Decoder) decodeRaw() (raw json.RawMessage, start int64) {
+	mustd.Must0(dec.locate(dec.decoder.Decode(&raw), 0))
+	return raw, dec.decoder.InputOffset() - int64(len(raw))
 }
 
 // Buffered returns a reader of the data remaining in the Decoder's buffer.
@@ -51,7 +175,35 @@ func (dec *Decoder) Buffered() iomust.Reader {
 
 // Decode reads the next JSON-encoded value from its input and stores it in v. Panics if an error occurs.
 func (dec *Decoder) Decode(v any) {
-	mustd.Must0(dec.decoder.Decode(v))
+	from := dec.decoder.InputOffset()
+	err := dec.decoder.Decode(v)
+	var typeErr *json.UnmarshalTypeError
+	if !errors.As(err, &typeErr) {
+		mustd.Must0(dec.locate(err, 0))
+		return
+	}
+	// Offsets of type errors are relative to the start of the value, which follows white space and a separator of the enclosing array or object.
+	data := dec.position.Input(from, dec.decoder.InputOffset())
+	i := len(data) - len(bytes.TrimLeft(data, " \t\r\n"))
+	if i < len(data) && (data[i] == ',' || data[i] == ':') {
+		i = len(data) - len(bytes.TrimLeft(data[i+1:], " \t\r\n"))
+	}
+	mustd.Must0(dec.locate(err, from+int64(i)))
+}
+
+// DecodeAs reads the next JSON-encoded value from the input of dec and returns it as a value of type T. Panics if an error occurs.
+// With Strict, unknown object keys are rejected for this value only. The other settings of dec such as UseNumber are respected.
+func DecodeAs[T any](dec *Decoder, opts ...DecodeOption) T {
+	o := newDecodeOptions(opts)
+	var v T
+	if !o.strict {
+		dec.Decode(&v)
+		return v
+	}
+	o.useNumber = dec.useNumber
+	raw, start := dec.decodeRaw()
+	mustd.Must0(dec.locate(unmarshal(raw, &v, o), start))
+	return v
 }
 
 // DisallowUnknownFields causes the Decoder to return an error when the destination is a struct and the input contains object keys which do not match any non-ignored, exported fields.
@@ -71,12 +223,15 @@ func (dec *Decoder) More() bool {
 
 // Token returns the next JSON token in the input stream. Panics if an error occurs.
 func (dec *Decoder) Token() json.Token {
-	return mustd.Must1(dec.decoder.Token())
+	token, err := dec.decoder.Token()
+	mustd.Must0(dec.locate(err, 0))
+	return token
 }
 
 // UseNumber causes the Decoder to unmarshal a number into an interface{} as a Number instead of as a float64.
 func (dec *Decoder) UseNumber() {
 	dec.decoder.UseNumber()
+	dec.useNumber = true
 }
 
 // Encoder wraps json.Encoder and provides panicking error handling.
@@ -2,9 +2,15 @@ package jsonmust_test
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/Jumpaku/go-mustd/encodingmust"
 	"github.com/Jumpaku/go-mustd/encodingmust/jsonmust"
 	"github.com/Jumpaku/go-mustd/iomust"
 )
@@ -126,6 +132,115 @@ func TestDecoder(t *testing.T) {
 	})
 }
 
+func TestDecodeErrorLocation(t *testing.T) {
+	type config struct {
+		Name string `json:"name"`
+		Port int    `json:"port"`
+	}
+	testCases := []struct {
+		name    string
+		decode  func()
+		line    int
+		column  int
+		excerpt string
+	}{
+		{
+			name: "Unmarshal syntax error",
+			decode: func() {
+				var v any
+				jsonmust.Unmarshal([]byte("{\n  \"name\": \"app\",\n  \"port\": 80x\n}"), &v)
+			},
+			line: 3, column: 13, excerpt: "  \"port\": 80x\n            ^",
+		},
+		{
+			name: "Unmarshal type error",
+			decode: func() {
+				var v config
+				jsonmust.Unmarshal([]byte("{\n  \"name\": \"app\",\n  \"port\": \"80\"\n}"), &v)
+			},
+			line: 3, column: 14, excerpt: "  \"port\": \"80\"\n             ^",
+		},
+		{
+			name: "UnmarshalAs strict type error",
+			decode: func() {
+				jsonmust.UnmarshalAs[config]([]byte("\n\n{\"name\": 1}"), jsonmust.Strict())
+			},
+			line: 3, column: 10, excerpt: "{\"name\": 1}\n         ^",
+		},
+		{
+			name: "Decoder.Decode type error",
+			decode: func() {
+				dec := jsonmust.NewDecoder(iomust.ReaderOf(strings.NewReader("{\"port\": 1}\n{\"port\": true}\n")))
+				var v config
+				dec.Decode(&v)
+				dec.Decode(&v)
+			},
+			line: 2, column: 13, excerpt: "{\"port\": true}\n            ^",
+		},
+		{
+			name: "Decoder.Decode type error after tokens",
+			decode: func() {
+				dec := jsonmust.NewDecoder(iomust.ReaderOf(strings.NewReader("[{\"port\": 1} ,\n  {\"port\": true}]")))
+				dec.Token()
+				var v config
+				dec.Decode(&v)
+				dec.Decode(&v)
+			},
+			line: 2, column: 15, excerpt: "  {\"port\": true}]\n              ^",
+		},
+		{
+			name: "Decoder.Decode syntax error",
+			decode: func() {
+				dec := jsonmust.NewDecoder(iomust.ReaderOf(strings.NewReader("[1,\n 2,,3]")))
+				var v any
+				dec.Decode(&v)
+			},
+			line: 2, column: 4, excerpt: " 2,,3]\n   ^",
+		},
+		{
+			name: "Decoder.Token syntax error",
+			decode: func() {
+				dec := jsonmust.NewDecoder(iomust.ReaderOf(strings.NewReader("[1 2]")))
+				dec.Token()
+				dec.Token()
+				dec.Token()
+			},
+			line: 1, column: 4, excerpt: "[1 2]\n   ^",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer func() {
+				err, _ := recover().(error)
+				var decodeErr *encodingmust.DecodeError
+				if !errors.As(err, &decodeErr) {
+					t.Fatalf("expected panic with *encodingmust.DecodeError, got %v", err)
+				}
+				if decodeErr.Line != tc.line || decodeErr.Column != tc.column {
+					t.Errorf("expected line %d, column %d, got line %d, column %d", tc.line, tc.column, decodeErr.Line, decodeErr.Column)
+				}
+				if decodeErr.Excerpt != tc.excerpt {
+					t.Errorf("expected excerpt %q, got %q", tc.excerpt, decodeErr.Excerpt)
+				}
+			}()
+			tc.decode()
+		})
+	}
+
+	t.Run("Decoder truncated input", func(t *testing.T) {
+		defer func() {
+			err, _ := recover().(error)
+			var decodeErr *encodingmust.DecodeError
+			if !errors.As(err, &decodeErr) || decodeErr.Line != 2 {
+				t.Errorf("expected *encodingmust.DecodeError on line 2, got %v", err)
+			}
+		}()
+		dec := jsonmust.NewDecoder(iomust.ReaderOf(strings.NewReader("{\"name\":\n\"app")))
+		var v any
+		dec.Decode(&v)
+	})
+}
+
 func TestEncoder(t *testing.T) {
 	t.Run("encode valid data", func(t *testing.T) {
 		buf := &bytes.Buffer{}
@@ -169,3 +284,638 @@ func TestEncoder(t *testing.T) {
 		}
 	})
 }
+
+type person struct {
+	Name string `json:"name"`
+	Age  int    `json:"age"`
+}
+
+func TestUnmarshalAs(t *testing.T) {
+	t.Run("returns typed value", func(t *testing.T) {
+		p := jsonmust.UnmarshalAs[person]([]byte(`{"name":"test","age":42,"extra":true}`))
+		if p.Name != "test" || p.Age != 42 {
+			t.Errorf("unexpected result: %+v", p)
+		}
+	})
+
+	t.Run("strict rejects unknown fields", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("UnmarshalAs did not panic with unknown field in strict mode")
+			}
+		}()
+		jsonmust.UnmarshalAs[person]([]byte(`{"name":"test","extra":true}`), jsonmust.Strict())
+	})
+
+	t.Run("strict rejects trailing data", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("UnmarshalAs did not panic with trailing data in strict mode")
+			}
+		}()
+		jsonmust.UnmarshalAs[person]([]byte(`{"name":"test"} {}`), jsonmust.Strict())
+	})
+
+	t.Run("strict accepts valid data", func(t *testing.T) {
+		p := jsonmust.UnmarshalAs[person]([]byte(" {\"name\":\"test\"}\n"), jsonmust.Strict())
+		if p.Name != "test" {
+			t.Errorf("unexpected result: %+v", p)
+		}
+	})
+}
+
+func TestReadWriteFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "test.json")
+
+	t.Run("WriteFile and ReadFileAs", func(t *testing.T) {
+		jsonmust.WriteFile(name, person{Name: "test", Age: 42}, 0600)
+
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := "{\n  \"name\": \"test\",\n  \"age\": 42\n}\n"
+		if string(data) != expected {
+			t.Errorf("expected %q, got %q", expected, data)
+		}
+		info, err := os.Stat(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != 0600 {
+			t.Errorf("expected mode 0600, got %v", info.Mode().Perm())
+		}
+
+		p := jsonmust.ReadFileAs[person](name, jsonmust.Strict())
+		if p.Name != "test" || p.Age != 42 {
+			t.Errorf("unexpected result: %+v", p)
+		}
+	})
+
+	t.Run("WriteFile leaves no temporary files", func(t *testing.T) {
+		jsonmust.WriteFile(name, person{Name: "overwritten"}, 0644)
+		entries, err := os.ReadDir(filepath.Dir(name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 1 {
+			t.Errorf("expected 1 file, got %d", len(entries))
+		}
+		if p := jsonmust.ReadFileAs[person](name); p.Name != "overwritten" {
+			t.Errorf("unexpected result: %+v", p)
+		}
+	})
+
+	t.Run("ReadFileAs non-existent file panics", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("ReadFileAs did not panic with non-existent file")
+			}
+		}()
+		jsonmust.ReadFileAs[person](filepath.Join(t.TempDir(), "missing.json"))
+	})
+}
+
+func TestDecoderSettings(t *testing.T) {
+	t.Run("UseNumber applies to Token and Decode", func(t *testing.T) {
+		dec := jsonmust.NewDecoder(iomust.ReaderOf(strings.NewReader(`1.5 {"n":2}`)))
+		dec.UseNumber()
+		if token, ok := dec.Token().(json.Number); !ok || token != "1.5" {
+			t.Errorf("expected json.Number token, got %#v", token)
+		}
+		var v map[string]any
+		dec.Decode(&v)
+		if n, ok := v["n"].(json.Number); !ok || n != "2" {
+			t.Errorf("expected json.Number, got %#v", v["n"])
+		}
+	})
+
+	t.Run("DisallowUnknownFields", func(t *testing.T) {
+		dec := jsonmust.NewDecoder(iomust.ReaderOf(strings.NewReader(`{"name":"a","extra":1}`)))
+		dec.DisallowUnknownFields()
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("Decode did not panic with unknown field")
+			}
+		}()
+		var p person
+		dec.Decode(&p)
+	})
+}
+
+func TestDecodeAs(t *testing.T) {
+	t.Run("decodes values in stream", func(t *testing.T) {
+		dec := jsonmust.NewDecoder(iomust.ReaderOf(strings.NewReader(`{"name":"a"} {"name":"b","extra":1}`)))
+		if p := jsonmust.DecodeAs[person](dec); p.Name != "a" {
+			t.Errorf("unexpected result: %+v", p)
+		}
+		if p := jsonmust.DecodeAs[person](dec); p.Name != "b" {
+			t.Errorf("unexpected result: %+v", p)
+		}
+	})
+
+	t.Run("strict rejects unknown fields", func(t *testing.T) {
+		dec := jsonmust.NewDecoder(iomust.ReaderOf(strings.NewReader(`{"name":"a"} {"name":"b","extra":1}`)))
+		if p := jsonmust.DecodeAs[person](dec, jsonmust.Strict()); p.Name != "a" {
+			t.Errorf("unexpected result: %+v", p)
+		}
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("DecodeAs did not panic with unknown field in strict mode")
+			}
+		}()
+		jsonmust.DecodeAs[person](dec, jsonmust.Strict())
+	})
+
+	t.Run("strict respects UseNumber", func(t *testing.T) {
+		dec := jsonmust.NewDecoder(iomust.ReaderOf(strings.NewReader(`{"n":1.5}`)))
+		dec.UseNumber()
+		v := jsonmust.DecodeAs[map[string]any](dec, jsonmust.Strict())
+		if n, ok := v["n"].(json.Number); !ok || n != "1.5" {
+			t.Errorf("expected json.Number, got %#v", v["n"])
+		}
+	})
+}
+
+func TestLinesReader(t *testing.T) {
+	input := "{\"name\":\"a\",\"age\":1}\n\n  {\"name\":\"b\"}\r\n{broken\n{\"name\":\"c\"}"
+
+	t.Run("All", func(t *testing.T) {
+		r := jsonmust.NewLinesReader(iomust.ReaderOf(strings.NewReader(`{"a":1}` + "\n[1, 2]\n\n\"x\"\n")))
+		var values []string
+		for v := range r.All() {
+			values = append(values, string(v))
+		}
+		expected := []string{`{"a":1}`, `[1, 2]`, `"x"`}
+		if strings.Join(values, "|") != strings.Join(expected, "|") {
+			t.Errorf("expected %q, got %q", expected, values)
+		}
+	})
+
+	t.Run("malformed line panics with line number", func(t *testing.T) {
+		r := jsonmust.NewLinesReader(iomust.ReaderOf(strings.NewReader(input)))
+		defer func() {
+			rec := recover()
+			err, _ := rec.(error)
+			var decodeErr *encodingmust.DecodeError
+			if !errors.As(err, &decodeErr) {
+				t.Fatalf("expected *encodingmust.DecodeError, got %v", rec)
+			}
+			if decodeErr.Line != 4 || decodeErr.Column != 2 || decodeErr.Offset != int64(strings.Index(input, "{broken")+1) {
+				t.Errorf("expected line 4, column 2 at the offset in the input, got %+v", decodeErr)
+			}
+			if decodeErr.Excerpt != "{broken\n ^" {
+				t.Errorf("unexpected excerpt %q", decodeErr.Excerpt)
+			}
+		}()
+		for range jsonmust.AllAs[person](r) {
+		}
+	})
+
+	t.Run("malformed lines are passed to error sink", func(t *testing.T) {
+		r := jsonmust.NewLinesReader(iomust.ReaderOf(strings.NewReader(input + "\n{\"age\":\"x\"}\n")))
+		var errs []*encodingmust.DecodeError
+		r.SetErrorSink(func(err *encodingmust.DecodeError) {
+			errs = append(errs, err)
+		})
+		var names []string
+		for p := range jsonmust.AllAs[person](r) {
+			names = append(names, p.Name)
+		}
+		if strings.Join(names, ",") != "a,b,c" {
+			t.Errorf("unexpected names: %q", names)
+		}
+		if len(errs) != 2 || errs[0].Line != 4 || errs[1].Line != 6 || errs[1].Column != 10 {
+			t.Errorf("unexpected errors: %v", errs)
+		}
+	})
+
+	t.Run("AllAs strict", func(t *testing.T) {
+		r := jsonmust.NewLinesReader(iomust.ReaderOf(strings.NewReader(`{"name":"a","extra":1}`)))
+		defer func() {
+			err, _ := recover().(error)
+			var decodeErr *encodingmust.DecodeError
+			if !errors.As(err, &decodeErr) || decodeErr.Line != 1 || decodeErr.Column != 0 {
+				t.Errorf("expected *encodingmust.DecodeError on line 1 for unknown field in strict mode, got %v", err)
+			}
+		}()
+		for range jsonmust.AllAs[person](r, jsonmust.Strict()) {
+		}
+	})
+}
+
+func TestLinesWriter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := jsonmust.NewLinesWriter(iomust.WriterOf(buf))
+	w.Write(person{Name: "a\nb", Age: 1})
+	w.Write(json.RawMessage("{\n  \"name\": \"c\"\n}"))
+	w.Write([]int{1, 2})
+
+	expected := "{\"name\":\"a\\nb\",\"age\":1}\n{\"name\":\"c\"}\n[1,2]\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+
+	r := jsonmust.NewLinesReader(iomust.ReaderOf(buf))
+	n := 0
+	for range r.All() {
+		n++
+	}
+	if n != 3 {
+		t.Errorf("expected 3 values, got %d", n)
+	}
+}
+
+func TestGet(t *testing.T) {
+	data := []byte(`{"spec":{"replicas":3,"containers":[{"image":"nginx:1.0","ports":[80,443]},{"image":"redis"}]},"a.b":{"c":true}}`)
+
+	t.Run("Get", func(t *testing.T) {
+		testcases := []struct {
+			path string
+			want any
+		}{
+			{path: "$.spec.replicas", want: float64(3)},
+			{path: "$.spec.containers[0].image", want: "nginx:1.0"},
+			{path: "$.spec.containers[-1].image", want: "redis"},
+			{path: "$['spec']['containers'][0].ports[1]", want: float64(443)},
+			{path: `$["a.b"].c`, want: true},
+		}
+		for _, tc := range testcases {
+			got := jsonmust.Get(data, tc.path)
+			if got != tc.want {
+				t.Errorf("%s: expected %v, got %v", tc.path, tc.want, got)
+			}
+		}
+	})
+
+	t.Run("GetAs", func(t *testing.T) {
+		type container struct {
+			Image string `json:"image"`
+			Ports []int  `json:"ports"`
+		}
+		c := jsonmust.GetAs[container](data, "$.spec.containers[0]")
+		if c.Image != "nginx:1.0" || len(c.Ports) != 2 {
+			t.Errorf("unexpected result: %+v", c)
+		}
+		if n := jsonmust.GetAs[int](data, "$.spec.replicas"); n != 3 {
+			t.Errorf("expected 3, got %d", n)
+		}
+	})
+
+	t.Run("Has", func(t *testing.T) {
+		if !jsonmust.Has(data, "$.spec.containers[1]") {
+			t.Error("Has should return true for existing path")
+		}
+		if jsonmust.Has(data, "$.spec.containers[2]") || jsonmust.Has(data, "$.spec.replicas.x") {
+			t.Error("Has should return false for missing path")
+		}
+	})
+
+	t.Run("errors pinpoint the failing segment", func(t *testing.T) {
+		testcases := []struct {
+			path string
+			at   string
+		}{
+			{path: "$.spec.containers[5].image", at: "$.spec.containers[5]"},
+			{path: "$.spec.missing.image", at: "$.spec.missing"},
+			{path: "$.spec.replicas[0]", at: "$.spec.replicas[0]"},
+			{path: "$.spec..x", at: "$.spec."},
+			{path: "$.spec[x]", at: "$.spec[x]"},
+			{path: "spec", at: "spec"},
+		}
+		for _, tc := range testcases {
+			func() {
+				defer func() {
+					err, ok := recover().(*jsonmust.PathError)
+					if !ok {
+						t.Errorf("%s: expected *PathError", tc.path)
+						return
+					}
+					if err.Path != tc.at {
+						t.Errorf("%s: expected error at %s, got %s", tc.path, tc.at, err.Path)
+					}
+				}()
+				jsonmust.Get(data, tc.path)
+			}()
+		}
+	})
+}
+
+func TestApplyPatch(t *testing.T) {
+	doc := []byte(`{"version":"1.0","spec":{"tags":["a","b"],"n":1},"old":true}`)
+
+	t.Run("operations", func(t *testing.T) {
+		patch := []byte(`[
+			{"op":"test","path":"/version","value":"1.0"},
+			{"op":"replace","path":"/version","value":"2.0"},
+			{"op":"add","path":"/spec/tags/1","value":"x"},
+			{"op":"add","path":"/spec/tags/-","value":"z"},
+			{"op":"remove","path":"/old"},
+			{"op":"copy","from":"/spec/n","path":"/spec/m"},
+			{"op":"move","from":"/spec/tags/0","path":"/first"},
+			{"op":"add","path":"/a~1b","value":null},
+			{"op":"test","path":"/spec/m","value":1.0}
+		]`)
+		got := jsonmust.ApplyPatch(doc, patch)
+		expected := `{"a/b":null,"first":"a","spec":{"m":1,"n":1,"tags":["x","b","z"]},"version":"2.0"}`
+		if string(got) != expected {
+			t.Errorf("expected %s, got %s", expected, got)
+		}
+	})
+
+	t.Run("replace root", func(t *testing.T) {
+		got := jsonmust.ApplyPatch(doc, []byte(`[{"op":"replace","path":"","value":[1]}]`))
+		if string(got) != `[1]` {
+			t.Errorf("expected [1], got %s", got)
+		}
+	})
+
+	t.Run("missing members", func(t *testing.T) {
+		small := []byte(`{"a":1}`)
+		testcases := []struct {
+			patch  string
+			member string
+		}{
+			{patch: `[{"op":"add","value":5}]`, member: "path"},
+			{patch: `[{"op":"remove"}]`, member: "path"},
+			{patch: `[{"op":"copy","path":"/b"}]`, member: "from"},
+			{patch: `[{"op":"move","path":"/b"}]`, member: "from"},
+			{patch: `[{"op":"add","path":"/b"}]`, member: "value"},
+			{patch: `[{"op":"replace","path":"/a"}]`, member: "value"},
+			{patch: `[{"op":"test","path":"/a"}]`, member: "value"},
+		}
+		for _, tc := range testcases {
+			func() {
+				defer func() {
+					r := recover()
+					err, ok := r.(error)
+					var pathErr *jsonmust.PathError
+					if !ok || !errors.As(err, &pathErr) || !strings.Contains(pathErr.Error(), fmt.Sprintf("missing member %q", tc.member)) {
+						t.Errorf("%s: expected *PathError for missing %q, got %v", tc.patch, tc.member, r)
+					}
+				}()
+				jsonmust.ApplyPatch(small, []byte(tc.patch))
+			}()
+		}
+		if got := jsonmust.ApplyPatch(small, []byte(`[{"op":"add","path":"/b","value":null}]`)); string(got) != `{"a":1,"b":null}` {
+			t.Errorf("expected explicit null value to be added, got %s", got)
+		}
+	})
+
+	t.Run("failure pinpoints the failing segment", func(t *testing.T) {
+		testcases := []struct {
+			patch string
+			at    string
+		}{
+			{patch: `[{"op":"replace","path":"/spec/tags/5","value":1}]`, at: "/spec/tags/5"},
+			{patch: `[{"op":"add","path":"/spec/missing/x","value":1}]`, at: "/spec/missing"},
+			{patch: `[{"op":"remove","path":"/spec/n/x"}]`, at: "/spec/n/x"},
+			{patch: `[{"op":"test","path":"/version","value":"2.0"}]`, at: "/version"},
+			{patch: `[{"op":"move","from":"/spec","path":"/spec/x"}]`, at: "/spec/x"},
+			{patch: `[{"op":"add","path":"/spec/tags/+1","value":1}]`, at: "/spec/tags/+1"},
+			{patch: `[{"op":"add","path":"/spec/tags/01","value":1}]`, at: "/spec/tags/01"},
+		}
+		for _, tc := range testcases {
+			func() {
+				defer func() {
+					r := recover()
+					err, ok := r.(error)
+					var pathErr *jsonmust.PathError
+					if !ok || !errors.As(err, &pathErr) {
+						t.Errorf("%s: expected error wrapping *PathError, got %v", tc.patch, r)
+						return
+					}
+					if pathErr.Path != tc.at {
+						t.Errorf("%s: expected error at %s, got %s", tc.patch, tc.at, pathErr.Path)
+					}
+				}()
+				jsonmust.ApplyPatch(doc, []byte(tc.patch))
+			}()
+		}
+	})
+
+	t.Run("unknown operation panics", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("ApplyPatch did not panic with unknown operation")
+			}
+		}()
+		jsonmust.ApplyPatch(doc, []byte(`[{"op":"unknown","path":"/version"}]`))
+	})
+
+	t.Run("trailing data panics", func(t *testing.T) {
+		for _, tc := range []struct{ doc, patch string }{
+			{doc: `{"a":1}}`, patch: `[]`},
+			{doc: `{"a":1}`, patch: `[]]`},
+			{doc: `{"a":1} 2`, patch: `[]`},
+		} {
+			func() {
+				defer func() {
+					if r := recover(); r == nil {
+						t.Errorf("ApplyPatch did not panic with %s and %s", tc.doc, tc.patch)
+					}
+				}()
+				jsonmust.ApplyPatch([]byte(tc.doc), []byte(tc.patch))
+			}()
+		}
+	})
+}
+
+func TestCreatePatch(t *testing.T) {
+	testcases := []struct {
+		original, modified string
+	}{
+		{original: `{"a":1,"b":{"c":[1,2,3]},"d":"x"}`, modified: `{"a":2,"b":{"c":[1,4]},"e":null}`},
+		{original: `[1,2]`, modified: `[1,2,3,{"x":1}]`},
+		{original: `{"a/b":{"~":1}}`, modified: `{"a/b":{"~":2}}`},
+		{original: `{"a":1}`, modified: `[1]`},
+		{original: `{"a":1}`, modified: `{"a":1}`},
+	}
+	for _, tc := range testcases {
+		patch := jsonmust.CreatePatch([]byte(tc.original), []byte(tc.modified))
+		got := jsonmust.ApplyPatch([]byte(tc.original), patch)
+		want := jsonmust.ApplyPatch([]byte(tc.modified), []byte(`[]`))
+		if string(got) != string(want) {
+			t.Errorf("%s -> %s: patch %s produced %s", tc.original, tc.modified, patch, got)
+		}
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	t.Run("ApplyMergePatch", func(t *testing.T) {
+		doc := []byte(`{"title":"Goodbye!","author":{"givenName":"John","familyName":"Doe"},"tags":["example","sample"],"content":"This will be unchanged"}`)
+		patch := []byte(`{"title":"Hello!","phoneNumber":"+01-123-456-7890","author":{"familyName":null},"tags":["example"]}`)
+		got := jsonmust.ApplyMergePatch(doc, patch)
+		expected := `{"author":{"givenName":"John"},"content":"This will be unchanged","phoneNumber":"+01-123-456-7890","tags":["example"],"title":"Hello!"}`
+		if string(got) != expected {
+			t.Errorf("expected %s, got %s", expected, got)
+		}
+	})
+
+	t.Run("CreateMergePatch", func(t *testing.T) {
+		original := []byte(`{"a":"b","c":{"d":"e","f":"g"},"h":[1]}`)
+		modified := []byte(`{"a":"z","c":{"d":"e"},"h":[1],"i":1}`)
+		patch := jsonmust.CreateMergePatch(original, modified)
+		expected := `{"a":"z","c":{"f":null},"i":1}`
+		if string(patch) != expected {
+			t.Errorf("expected %s, got %s", expected, patch)
+		}
+		if got := jsonmust.ApplyMergePatch(original, patch); string(got) != `{"a":"z","c":{"d":"e"},"h":[1],"i":1}` {
+			t.Errorf("unexpected patched document: %s", got)
+		}
+	})
+}
+
+func TestSchema(t *testing.T) {
+	schema := jsonmust.CompileSchema([]byte(`{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"type": "object",
+		"required": ["name", "version", "replicas", "containers"],
+		"properties": {
+			"name": {"type": "string", "pattern": "^[a-z][a-z0-9-]*$", "maxLength": 16},
+			"version": {"type": "string"},
+			"replicas": {"type": "integer", "minimum": 1, "maximum": 10},
+			"ratio": {"type": "number", "exclusiveMinimum": 0, "multipleOf": 0.1},
+			"env": {"enum": ["dev", "prod"]},
+			"containers": {"type": "array", "minItems": 1, "items": {"$ref": "#/$defs/container"}},
+			"labels": {"type": "object", "additionalProperties": {"type": "string"}}
+		},
+		"additionalProperties": false,
+		"$defs": {
+			"container": {
+				"type": "object",
+				"required": ["image"],
+				"properties": {
+					"image": {"type": "string", "minLength": 1},
+					"ports": {"type": "array", "uniqueItems": true, "items": {"type": "integer"}}
+				}
+			}
+		}
+	}`))
+
+	t.Run("valid document", func(t *testing.T) {
+		schema.Validate([]byte(`{"name":"web","version":"1","replicas":3,"ratio":0.3,"env":"prod","containers":[{"image":"nginx","ports":[80,443]}],"labels":{"a":"b"}}`))
+	})
+
+	t.Run("invalid document lists every violation", func(t *testing.T) {
+		defer func() {
+			err, ok := recover().(*jsonmust.ValidationError)
+			if !ok {
+				t.Fatal("Validate did not panic with *ValidationError")
+			}
+			got := map[string]string{}
+			for _, v := range err.Violations {
+				got[v.InstanceLocation] = v.KeywordLocation
+			}
+			expected := map[string]string{
+				"":                      "/required",
+				"/name":                 "/properties/name/pattern",
+				"/replicas":             "/properties/replicas/maximum",
+				"/ratio":                "/properties/ratio/multipleOf",
+				"/env":                  "/properties/env/enum",
+				"/containers/0/image":   "/$defs/container/properties/image/minLength",
+				"/containers/1/ports":   "/$defs/container/properties/ports/uniqueItems",
+				"/containers/1/ports/2": "/$defs/container/properties/ports/items/type",
+				"/labels/a":             "/properties/labels/additionalProperties/type",
+				"/unknown":              "/additionalProperties",
+			}
+			for at, keyword := range expected {
+				if got[at] != keyword {
+					t.Errorf("expected violation of %s at %q, got %q", keyword, at, got[at])
+				}
+			}
+			if len(err.Violations) != len(expected) {
+				t.Errorf("expected %d violations, got %d: %v", len(expected), len(err.Violations), err)
+			}
+			if !strings.Contains(err.Error(), "/containers/1/ports/2") {
+				t.Errorf("expected instance location in message, got %q", err.Error())
+			}
+		}()
+		schema.Validate([]byte(`{"name":"Web","replicas":11,"ratio":0.25,"env":"test","containers":[{"image":""},{"image":"x","ports":[1,1,"2"]}],"labels":{"a":1},"unknown":1}`))
+	})
+
+	t.Run("combinators", func(t *testing.T) {
+		s := jsonmust.CompileSchema([]byte(`{"anyOf":[{"type":"string"},{"type":"integer"}],"not":{"const":"x"},"oneOf":[{"type":"string"},{"type":"integer","minimum":0}]}`))
+		s.Validate([]byte(`"a"`))
+		s.Validate([]byte(`1`))
+		for _, data := range []string{`"x"`, `true`, `-1`} {
+			func() {
+				defer func() {
+					if r := recover(); r == nil {
+						t.Errorf("Validate did not panic with %s", data)
+					}
+				}()
+				s.Validate([]byte(data))
+			}()
+		}
+	})
+
+	t.Run("recursive reference", func(t *testing.T) {
+		s := jsonmust.CompileSchema([]byte(`{"type":"object","properties":{"children":{"type":"array","items":{"$ref":"#"}}}}`))
+		s.Validate([]byte(`{"children":[{"children":[]}]}`))
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("Validate did not panic with invalid nested value")
+			}
+		}()
+		s.Validate([]byte(`{"children":[{"children":[1]}]}`))
+	})
+
+	t.Run("remote reference requires loader", func(t *testing.T) {
+		schema := []byte(`{"$id":"https://example.com/root.json","$ref":"common.json#/$defs/name"}`)
+		func() {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Error("CompileSchema did not panic with remote reference without loader")
+				}
+			}()
+			jsonmust.CompileSchema(schema)
+		}()
+
+		var loaded []string
+		s := jsonmust.CompileSchema(schema, jsonmust.WithSchemaLoader(func(uri string) []byte {
+			loaded = append(loaded, uri)
+			return []byte(`{"$defs":{"name":{"type":"string"}}}`)
+		}))
+		if len(loaded) != 1 || loaded[0] != "https://example.com/common.json" {
+			t.Errorf("unexpected loaded URIs: %q", loaded)
+		}
+		s.Validate([]byte(`"name"`))
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("Validate did not panic with invalid value")
+			}
+		}()
+		s.Validate([]byte(`1`))
+	})
+
+	t.Run("integers with zero fractional part and annotations", func(t *testing.T) {
+		s := jsonmust.CompileSchema([]byte(`{"title":"t","format":"x","x-extension":1,"maxItems":2.0}`))
+		s.Validate([]byte(`[1,2]`))
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("Validate did not panic with too many items")
+			}
+		}()
+		s.Validate([]byte(`[1,2,3]`))
+	})
+
+	t.Run("malformed schema panics", func(t *testing.T) {
+		for _, schema := range []string{
+			`{"type":"unknown"}`, `{"minimum":"1"}`, `{"pattern":"("}`, `{"$ref":"#/$defs/missing"}`, `1`, `{"minItems":1.5}`,
+			`{"$ref":"#"}`,
+			`{"$ref":"#/$defs/a","$defs":{"a":{"allOf":[{"$ref":"#/$defs/b"}]},"b":{"not":{"$ref":"#/$defs/a"}}}}`,
+			`{"if":{"type":"string"}}`,
+			`{"properties":{"a":{"$id":"https://example.com/a.json"}}}`,
+		} {
+			func() {
+				defer func() {
+					if r := recover(); r == nil {
+						t.Errorf("CompileSchema did not panic with %s", schema)
+					}
+				}()
+				jsonmust.CompileSchema([]byte(schema))
+			}()
+		}
+	})
+}
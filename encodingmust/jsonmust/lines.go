@@ -0,0 +1,143 @@
+package jsonmust
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"iter"
+
+	"github.com/Jumpaku/go-mustd"
+	"github.com/Jumpaku/go-mustd/encodingmust"
+	"github.com/Jumpaku/go-mustd/iomust"
+)
+
+// LinesReader reads JSON Lines (newline-delimited JSON) input, in which each line holds one JSON value.
+// Empty lines are ignored. Malformed lines are reported as *encodingmust.DecodeError with the line, the column and the offset in the input.
+type LinesReader struct {
+	reader  *bufio.Reader
+	line    int
+	offset  int64
+	current []byte
+	// currentOffset is the offset of current, which is the last read line without surrounding white space, in the input.
+	currentOffset int64
+	errSink       func(err *encodingmust.DecodeError)
+}
+
+// NewLinesReader returns a new LinesReader that reads from r.
+func NewLinesReader(r iomust.Reader) *LinesReader {
+	return &LinesReader{reader: bufio.NewReader(r.Reader())}
+}
+
+// SetErrorSink makes the reader pass malformed lines to sink and skip them instead of panicking.
+// A nil sink restores the default behavior.
+func (r *LinesReader) SetErrorSink(sink func(err *encodingmust.DecodeError)) {
+	r.errSink = sink
+}
+
+// Line returns the line number of the last read line, starting at 1.
+func (r *LinesReader) Line() int {
+	return r.line
+}
+
+// Read reads the next JSON value. It returns false at the end of the input.
+// Panics with a *encodingmust.DecodeError if the line is not valid JSON and no error sink is set, or if an error occurs reading the input.
+func (r *LinesReader) Read() (value json.RawMessage, ok bool) {
+	for {
+		line, ok := r.readLine()
+		if !ok {
+			return nil, false
+		}
+		var raw json.RawMessage
+		if err := json.Unmarshal(line, &raw); err != nil {
+			r.malformed(err)
+			continue
+		}
+		return raw, true
+	}
+}
+
+// All returns an iterator over the remaining JSON values.
+// Panics as Read during iteration.
+func (r *LinesReader) All() iter.Seq[json.RawMessage] {
+	return func(yield func(json.RawMessage) bool) {
+		for {
+			value, ok := r.Read()
+			if !ok || !yield(value) {
+				return
+			}
+		}
+	}
+}
+
+// AllAs returns an iterator over the remaining JSON values of r decoded as values of type T.
+// Panics with a *encodingmust.DecodeError if a value cannot be decoded and no error sink is set, or if an error occurs reading the input.
+func AllAs[T any](r *LinesReader, opts ...DecodeOption) iter.Seq[T] {
+	o := newDecodeOptions(opts)
+	return func(yield func(T) bool) {
+		for value := range r.All() {
+			var v T
+			if err := unmarshal(value, &v, o); err != nil {
+				r.malformed(err)
+				continue
+			}
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// readLine returns the next non-empty line without the line terminator.
+func (r *LinesReader) readLine() ([]byte, bool) {
+	for {
+		line, err := r.reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			mustd.Must0(err)
+		}
+		if len(line) == 0 && err == io.EOF {
+			return nil, false
+		}
+		r.line++
+		start := r.offset
+		r.offset += int64(len(line))
+		trimmed := bytes.TrimSpace(line)
+		if len(trimmed) > 0 {
+			r.current = trimmed
+			r.currentOffset = start + int64(len(line)-len(bytes.TrimLeft(line, " \t\r\n")))
+			return trimmed, true
+		}
+	}
+}
+
+// malformed passes err located in the current line to the error sink, or panics if no error sink is set.
+func (r *LinesReader) malformed(err error) {
+	located := locateError(err, 0, func(offset int64, err error) error {
+		return encodingmust.NewDecodeError(r.current, offset, err)
+	})
+	decodeErr, ok := located.(*encodingmust.DecodeError)
+	if !ok {
+		decodeErr = encodingmust.NewDecodeErrorAt(r.current, 1, 0, err)
+	}
+	decodeErr.Line = r.line
+	decodeErr.Offset += r.currentOffset
+	if r.errSink == nil {
+		panic(decodeErr)
+	}
+	r.errSink(decodeErr)
+}
+
+// LinesWriter writes JSON Lines (newline-delimited JSON) output, in which each line holds one JSON value.
+type LinesWriter struct {
+	writer iomust.Writer
+}
+
+// NewLinesWriter returns a new LinesWriter that writes to w.
+func NewLinesWriter(w iomust.Writer) *LinesWriter {
+	return &LinesWriter{writer: w}
+}
+
+// Write writes the compact JSON encoding of v followed by a newline to the underlying writer in a single write. Panics if an error occurs.
+func (w *LinesWriter) Write(v any) {
+	w.writer.Write(append(Marshal(v), '\n'))
+}
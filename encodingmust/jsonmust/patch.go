@@ -0,0 +1,506 @@
+package jsonmust
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Jumpaku/go-mustd"
+)
+
+// PatchOperation is an operation of a JSON Patch defined in RFC 6902.
+type PatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// ApplyPatch applies the JSON Patch (RFC 6902) patch to the JSON document doc and returns the patched document.
+// Panics if an operation fails with an error wrapping a *PathError that locates the failing segment of the JSON Pointer,
+// or if an operation lacks a member required by RFC 6902 with an error wrapping a *PathError naming the member.
+// Panics if doc or patch is not valid JSON.
+func ApplyPatch(doc, patch []byte) []byte {
+	var ops []PatchOperation
+	mustd.Must0(json.Unmarshal(patch, &ops))
+	// The members are decoded also as a map to tell missing members from empty ones.
+	var members []map[string]json.RawMessage
+	mustd.Must0(json.Unmarshal(patch, &members))
+	v := mustd.Must1(decodeValue(doc))
+	for i, op := range ops {
+		err := checkMembers(op, members[i])
+		if err == nil {
+			v, err = applyOperation(v, op)
+		}
+		if err != nil {
+			panic(fmt.Errorf("jsonmust: patch operation %d (%s %s): %w", i, op.Op, op.Path, err))
+		}
+	}
+	return Marshal(v)
+}
+
+// CreatePatch returns a JSON Patch (RFC 6902) that transforms the JSON document original into modified.
+// Panics if original or modified is not valid JSON.
+func CreatePatch(original, modified []byte) []byte {
+	o := mustd.Must1(decodeValue(original))
+	m := mustd.Must1(decodeValue(modified))
+	ops := diffValues(nil, "", o, m)
+	if ops == nil {
+		ops = []PatchOperation{}
+	}
+	return Marshal(ops)
+}
+
+// ApplyMergePatch applies the JSON Merge Patch (RFC 7386) patch to the JSON document doc and returns the patched document.
+// Panics if doc or patch is not valid JSON.
+func ApplyMergePatch(doc, patch []byte) []byte {
+	d := mustd.Must1(decodeValue(doc))
+	p := mustd.Must1(decodeValue(patch))
+	return Marshal(mergePatch(d, p))
+}
+
+// CreateMergePatch returns a JSON Merge Patch (RFC 7386) that transforms the JSON document original into modified.
+// Because a merge patch cannot set a member to null, members set to null in modified are removed by the patch.
+// Panics if original or modified is not valid JSON.
+func CreateMergePatch(original, modified []byte) []byte {
+	o := mustd.Must1(decodeValue(original))
+	m := mustd.Must1(decodeValue(modified))
+	return Marshal(diffMerge(o, m))
+}
+
+// decodeValue decodes a JSON value preserving the representation of numbers.
+func decodeValue(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		if err == nil {
+			err = fmt.Errorf("json: unexpected data after top-level value")
+		}
+		return nil, err
+	}
+	return v, nil
+}
+
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, &PathError{Path: pointer, Err: fmt.Errorf("JSON Pointer must start with /")}
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func escapePointerToken(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+// pointerPrefix returns the JSON Pointer consisting of the first n tokens.
+func pointerPrefix(tokens []string, n int) string {
+	var b strings.Builder
+	for _, token := range tokens[:n] {
+		b.WriteString("/" + escapePointerToken(token))
+	}
+	return b.String()
+}
+
+// arrayIndex parses token as an index of array of length n. If allowEnd is true, "-" and n are accepted as the end of the array.
+func arrayIndex(token string, n int, allowEnd bool) (int, error) {
+	if token == "-" && allowEnd {
+		return n, nil
+	}
+	// RFC 6901 allows only "0" or digits without leading zeros.
+	valid := token != "" && strings.Trim(token, "0123456789") == "" && (token == "0" || token[0] != '0')
+	i, err := strconv.Atoi(token)
+	if !valid || err != nil {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	if i > n || i == n && !allowEnd {
+		return 0, fmt.Errorf("index %d out of range with length %d", i, n)
+	}
+	return i, nil
+}
+
+// resolve returns the value at the JSON Pointer given as tokens in v.
+func resolve(v any, tokens []string) (any, error) {
+	for i, token := range tokens {
+		switch c := v.(type) {
+		case map[string]any:
+			child, ok := c[token]
+			if !ok {
+				return nil, &PathError{Path: pointerPrefix(tokens, i+1), Err: fmt.Errorf("member %q not found", token)}
+			}
+			v = child
+		case []any:
+			index, err := arrayIndex(token, len(c), false)
+			if err != nil {
+				return nil, &PathError{Path: pointerPrefix(tokens, i+1), Err: err}
+			}
+			v = c[index]
+		default:
+			return nil, &PathError{Path: pointerPrefix(tokens, i+1), Err: fmt.Errorf("%s is not a container", kindOfValue(v))}
+		}
+	}
+	return v, nil
+}
+
+// update replaces the value at the JSON Pointer given as tokens in doc with the result of fn applied to the parent container and the last token.
+func update(doc any, tokens []string, fn func(parent any, token string, at string) (any, error)) (any, error) {
+	if len(tokens) == 0 {
+		return fn(nil, "", "")
+	}
+	parent, err := resolve(doc, tokens[:len(tokens)-1])
+	if err != nil {
+		return nil, err
+	}
+	updated, err := fn(parent, tokens[len(tokens)-1], pointerPrefix(tokens, len(tokens)))
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 1 {
+		return updated, nil
+	}
+	// Arrays may have been reallocated, so the updated parent is set to the grandparent.
+	return update(doc, tokens[:len(tokens)-1], func(grandparent any, token string, at string) (any, error) {
+		return setChild(grandparent, token, updated), nil
+	})
+}
+
+func setChild(parent any, token string, child any) any {
+	switch c := parent.(type) {
+	case map[string]any:
+		c[token] = child
+		return c
+	case []any:
+		i, _ := strconv.Atoi(token)
+		c[i] = child
+		return c
+	}
+	return child
+}
+
+func addValue(doc any, tokens []string, value any) (any, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	return update(doc, tokens, func(parent any, token string, at string) (any, error) {
+		switch c := parent.(type) {
+		case map[string]any:
+			c[token] = value
+			return c, nil
+		case []any:
+			i, err := arrayIndex(token, len(c), true)
+			if err != nil {
+				return nil, &PathError{Path: at, Err: err}
+			}
+			return slices.Insert(c, i, value), nil
+		default:
+			return nil, &PathError{Path: at, Err: fmt.Errorf("%s is not a container", kindOfValue(parent))}
+		}
+	})
+}
+
+func removeValue(doc any, tokens []string) (any, error) {
+	if len(tokens) == 0 {
+		return nil, &PathError{Path: "", Err: fmt.Errorf("cannot remove the root")}
+	}
+	return update(doc, tokens, func(parent any, token string, at string) (any, error) {
+		switch c := parent.(type) {
+		case map[string]any:
+			if _, ok := c[token]; !ok {
+				return nil, &PathError{Path: at, Err: fmt.Errorf("member %q not found", token)}
+			}
+			delete(c, token)
+			return c, nil
+		case []any:
+			i, err := arrayIndex(token, len(c), false)
+			if err != nil {
+				return nil, &PathError{Path: at, Err: err}
+			}
+			return slices.Delete(c, i, i+1), nil
+		default:
+			return nil, &PathError{Path: at, Err: fmt.Errorf("%s is not a container", kindOfValue(parent))}
+		}
+	})
+}
+
+// checkMembers returns a *PathError if a member required by the operation is missing in members,
+// which are the members of the JSON object of op: "path" for every operation, "from" for move and copy, and "value" for add, replace and test.
+func checkMembers(op PatchOperation, members map[string]json.RawMessage) error {
+	required := []string{"path"}
+	switch op.Op {
+	case "move", "copy":
+		required = append(required, "from")
+	case "add", "replace", "test":
+		required = append(required, "value")
+	}
+	for _, name := range required {
+		if _, ok := members[name]; !ok {
+			return &PathError{Path: op.Path, Err: fmt.Errorf("missing member %q", name)}
+		}
+	}
+	return nil
+}
+
+func applyOperation(doc any, op PatchOperation) (any, error) {
+	tokens, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+	value := func() (any, error) {
+		if op.Value == nil {
+			return nil, fmt.Errorf("missing value")
+		}
+		return decodeValue(op.Value)
+	}
+	switch op.Op {
+	case "add":
+		v, err := value()
+		if err != nil {
+			return nil, err
+		}
+		return addValue(doc, tokens, v)
+	case "remove":
+		return removeValue(doc, tokens)
+	case "replace":
+		v, err := value()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := resolve(doc, tokens); err != nil {
+			return nil, err
+		}
+		if doc, err = removeValueOrRoot(doc, tokens); err != nil {
+			return nil, err
+		}
+		return addValue(doc, tokens, v)
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		v, err := resolve(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "move" {
+			if op.Path != op.From && strings.HasPrefix(op.Path, op.From+"/") {
+				return nil, &PathError{Path: op.Path, Err: fmt.Errorf("cannot move a value into its child %s", op.From)}
+			}
+			if doc, err = removeValueOrRoot(doc, from); err != nil {
+				return nil, err
+			}
+		} else {
+			v = deepCopy(v)
+		}
+		return addValue(doc, tokens, v)
+	case "test":
+		v, err := value()
+		if err != nil {
+			return nil, err
+		}
+		actual, err := resolve(doc, tokens)
+		if err != nil {
+			return nil, err
+		}
+		if !equalValues(actual, v) {
+			return nil, &PathError{Path: op.Path, Err: fmt.Errorf("test failed: value is %s", Marshal(actual))}
+		}
+		return doc, nil
+	default:
+		return nil, fmt.Errorf("unknown operation %q", op.Op)
+	}
+}
+
+func removeValueOrRoot(doc any, tokens []string) (any, error) {
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+	return removeValue(doc, tokens)
+}
+
+func kindOfValue(v any) string {
+	switch v.(type) {
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	default:
+		return "number"
+	}
+}
+
+func deepCopy(v any) any {
+	switch c := v.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(c))
+		for k, child := range c {
+			m[k] = deepCopy(child)
+		}
+		return m
+	case []any:
+		a := make([]any, len(c))
+		for i, child := range c {
+			a[i] = deepCopy(child)
+		}
+		return a
+	default:
+		return v
+	}
+}
+
+func equalValues(a, b any) bool {
+	switch a := a.(type) {
+	case map[string]any:
+		b, ok := b.(map[string]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for k, av := range a {
+			bv, ok := b[k]
+			if !ok || !equalValues(av, bv) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		b, ok := b.([]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !equalValues(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	case json.Number:
+		b, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		if a == b {
+			return true
+		}
+		af, aErr := a.Float64()
+		bf, bErr := b.Float64()
+		return aErr == nil && bErr == nil && af == bf
+	default:
+		return a == b
+	}
+}
+
+func diffValues(ops []PatchOperation, path string, original, modified any) []PatchOperation {
+	switch o := original.(type) {
+	case map[string]any:
+		m, ok := modified.(map[string]any)
+		if !ok {
+			break
+		}
+		for _, k := range sortedKeys(o) {
+			if _, ok := m[k]; !ok {
+				ops = append(ops, PatchOperation{Op: "remove", Path: path + "/" + escapePointerToken(k)})
+			}
+		}
+		for _, k := range sortedKeys(m) {
+			childPath := path + "/" + escapePointerToken(k)
+			if ov, ok := o[k]; ok {
+				ops = diffValues(ops, childPath, ov, m[k])
+			} else {
+				ops = append(ops, PatchOperation{Op: "add", Path: childPath, Value: Marshal(m[k])})
+			}
+		}
+		return ops
+	case []any:
+		m, ok := modified.([]any)
+		if !ok {
+			break
+		}
+		for i := range min(len(o), len(m)) {
+			ops = diffValues(ops, path+"/"+strconv.Itoa(i), o[i], m[i])
+		}
+		for i := len(o) - 1; i >= len(m); i-- {
+			ops = append(ops, PatchOperation{Op: "remove", Path: path + "/" + strconv.Itoa(i)})
+		}
+		for i := len(o); i < len(m); i++ {
+			ops = append(ops, PatchOperation{Op: "add", Path: path + "/" + strconv.Itoa(i), Value: Marshal(m[i])})
+		}
+		return ops
+	}
+	if !equalValues(original, modified) {
+		ops = append(ops, PatchOperation{Op: "replace", Path: path, Value: Marshal(modified)})
+	}
+	return ops
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func mergePatch(target, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]any)
+	if !ok {
+		t = map[string]any{}
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+		} else {
+			t[k] = mergePatch(t[k], v)
+		}
+	}
+	return t
+}
+
+func diffMerge(original, modified any) any {
+	o, ok := original.(map[string]any)
+	m, ok2 := modified.(map[string]any)
+	if !ok || !ok2 {
+		return modified
+	}
+	patch := map[string]any{}
+	for k := range o {
+		if mv, ok := m[k]; !ok || mv == nil {
+			patch[k] = nil
+		}
+	}
+	for k, mv := range m {
+		if mv == nil {
+			continue
+		}
+		ov, ok := o[k]
+		if !ok {
+			patch[k] = mv
+		} else if !equalValues(ov, mv) {
+			patch[k] = diffMerge(ov, mv)
+		}
+	}
+	return patch
+}
@@ -0,0 +1,185 @@
+package jsonmust
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Jumpaku/go-mustd"
+)
+
+// PathError describes a failure at a location in a JSON document.
+// Path is the location up to and including the segment where the failure occurred,
+// written in the syntax of the path given by the caller, that is JSONPath or JSON Pointer.
+type PathError struct {
+	Path string
+	Err  error
+}
+
+func (e *PathError) Error() string {
+	return fmt.Sprintf("jsonmust: at %s: %v", e.Path, e.Err)
+}
+
+func (e *PathError) Unwrap() error {
+	return e.Err
+}
+
+// Get returns the value in the JSON-encoded data at path, decoded as Unmarshal does into an interface value.
+// The path is a JSONPath consisting of the root $ followed by member accesses .name or ['name'] and array accesses [index],
+// where a negative index counts from the end of the array, for example $.spec.containers[0].image.
+// Panics with a *PathError if the path does not exist or is malformed, or panics if data is not valid JSON.
+func Get(data []byte, path string) any {
+	return GetAs[any](data, path)
+}
+
+// GetAs returns the value in the JSON-encoded data at path as a value of type T. See Get for the syntax of path.
+// Panics with a *PathError if the path does not exist or is malformed, or panics if an error occurs decoding the value.
+func GetAs[T any](data []byte, path string, opts ...DecodeOption) T {
+	raw := mustd.Must1(getRaw(data, path))
+	var v T
+	if err := unmarshal(raw, &v, newDecodeOptions(opts)); err != nil {
+		panic(&PathError{Path: path, Err: err})
+	}
+	return v
+}
+
+// Has reports whether the JSON-encoded data has a value at path. See Get for the syntax of path.
+// Panics if the path is malformed or data is not valid JSON.
+func Has(data []byte, path string) bool {
+	segments := mustd.Must1(parseJSONPath(path))
+	_, err := lookupRaw(data, path, segments)
+	var pathErr *PathError
+	if errors.As(err, &pathErr) {
+		return false
+	}
+	mustd.Must0(err)
+	return true
+}
+
+// jsonPathSegment is a member access or an array access in a JSONPath.
+type jsonPathSegment struct {
+	key     string
+	index   int
+	isIndex bool
+	// end is the offset of the end of the segment in the path.
+	end int
+}
+
+func getRaw(data []byte, path string) (json.RawMessage, error) {
+	segments, err := parseJSONPath(path)
+	if err != nil {
+		return nil, err
+	}
+	return lookupRaw(data, path, segments)
+}
+
+func lookupRaw(data []byte, path string, segments []jsonPathSegment) (json.RawMessage, error) {
+	current := json.RawMessage(data)
+	if !json.Valid(current) {
+		var v any
+		return nil, json.Unmarshal(data, &v)
+	}
+	for _, seg := range segments {
+		at := path[:seg.end]
+		current = bytes.TrimSpace(current)
+		if seg.isIndex {
+			var array []json.RawMessage
+			if len(current) == 0 || current[0] != '[' || json.Unmarshal(current, &array) != nil {
+				return nil, &PathError{Path: at, Err: fmt.Errorf("%s is not an array", kindOf(current))}
+			}
+			i := seg.index
+			if i < 0 {
+				i += len(array)
+			}
+			if i < 0 || i >= len(array) {
+				return nil, &PathError{Path: at, Err: fmt.Errorf("index %d out of range with length %d", seg.index, len(array))}
+			}
+			current = array[i]
+		} else {
+			var object map[string]json.RawMessage
+			if len(current) == 0 || current[0] != '{' || json.Unmarshal(current, &object) != nil {
+				return nil, &PathError{Path: at, Err: fmt.Errorf("%s is not an object", kindOf(current))}
+			}
+			v, ok := object[seg.key]
+			if !ok {
+				return nil, &PathError{Path: at, Err: fmt.Errorf("member %q not found", seg.key)}
+			}
+			current = v
+		}
+	}
+	return current, nil
+}
+
+// kindOf returns the kind of the JSON value.
+func kindOf(v json.RawMessage) string {
+	if len(v) == 0 {
+		return "empty value"
+	}
+	switch v[0] {
+	case '{':
+		return "object"
+	case '[':
+		return "array"
+	case '"':
+		return "string"
+	case 't', 'f':
+		return "boolean"
+	case 'n':
+		return "null"
+	default:
+		return "number"
+	}
+}
+
+func parseJSONPath(path string) ([]jsonPathSegment, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, &PathError{Path: path, Err: fmt.Errorf("path must start with $")}
+	}
+	var segments []jsonPathSegment
+	pos := 1
+	for pos < len(path) {
+		start := pos
+		switch path[pos] {
+		case '.':
+			pos++
+			for pos < len(path) && path[pos] != '.' && path[pos] != '[' {
+				pos++
+			}
+			if pos == start+1 {
+				return nil, &PathError{Path: path[:pos], Err: fmt.Errorf("empty member name")}
+			}
+			segments = append(segments, jsonPathSegment{key: path[start+1 : pos], end: pos})
+		case '[':
+			end := strings.IndexByte(path[pos:], ']')
+			if q := path[min(pos+1, len(path)-1)]; q == '\'' || q == '"' {
+				end = strings.Index(path[pos+2:], string(q)+"]")
+				if end >= 0 {
+					end += 3
+				}
+			}
+			if end < 0 {
+				return nil, &PathError{Path: path, Err: fmt.Errorf("unterminated bracket at offset %d", start)}
+			}
+			pos += end + 1
+			inner := path[start+1 : pos-1]
+			if inner == "" {
+				return nil, &PathError{Path: path[:pos], Err: fmt.Errorf("empty brackets")}
+			}
+			if q := inner[0]; q == '\'' || q == '"' {
+				segments = append(segments, jsonPathSegment{key: inner[1 : len(inner)-1], end: pos})
+				break
+			}
+			index, err := strconv.Atoi(inner)
+			if err != nil {
+				return nil, &PathError{Path: path[:pos], Err: fmt.Errorf("invalid array index %q", inner)}
+			}
+			segments = append(segments, jsonPathSegment{index: index, isIndex: true, end: pos})
+		default:
+			return nil, &PathError{Path: path[:pos+1], Err: fmt.Errorf("unexpected character %q", path[pos])}
+		}
+	}
+	return segments, nil
+}
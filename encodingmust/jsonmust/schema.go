@@ -0,0 +1,652 @@
+package jsonmust
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math/big"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Jumpaku/go-mustd"
+)
+
+// Schema is a compiled JSON Schema.
+// It supports a subset of draft 2020-12: $ref to JSON Pointers in the same document or other documents loaded by WithSchemaLoader,
+// $defs, $id of the root schema, type, enum, const, the numeric, string, array and object validation keywords,
+// prefixItems, items, properties, patternProperties, additionalProperties, allOf, anyOf, oneOf and not.
+// The other keywords of draft 2020-12 such as if, contains, unevaluatedProperties, $anchor and $id of subschemas are rejected,
+// while annotations such as title and format and keywords unknown to draft 2020-12 are ignored.
+type Schema struct {
+	root *schemaNode
+}
+
+// SchemaOption configures CompileSchema.
+type SchemaOption func(*schemaCompiler)
+
+// WithSchemaLoader returns a SchemaOption that resolves $ref to other documents by loader,
+// which returns the schema document identified by the absolute URI without fragment.
+// Without a loader, references to other documents are rejected, so that compiling a schema never accesses the network.
+func WithSchemaLoader(loader func(uri string) []byte) SchemaOption {
+	return func(c *schemaCompiler) {
+		c.loader = loader
+	}
+}
+
+// Violation describes a location in a JSON document violating a schema.
+type Violation struct {
+	// InstanceLocation is the JSON Pointer to the violating value in the validated document.
+	InstanceLocation string
+	// KeywordLocation is the JSON Pointer to the violated keyword in the schema, prefixed by the URI of the schema document if it is not the root.
+	KeywordLocation string
+	Message         string
+}
+
+// ValidationError lists every violation found by Schema.Validate.
+type ValidationError struct {
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "jsonmust: %d schema violation(s):", len(e.Violations))
+	for _, v := range e.Violations {
+		at := v.InstanceLocation
+		if at == "" {
+			at = "(root)"
+		}
+		fmt.Fprintf(&b, "\n- %s: %s (%s)", at, v.Message, v.KeywordLocation)
+	}
+	return b.String()
+}
+
+// CompileSchema compiles the JSON Schema document schema. Panics if the schema is not valid JSON or is malformed.
+func CompileSchema(schema []byte, opts ...SchemaOption) *Schema {
+	c := &schemaCompiler{docs: map[string]any{}, nodes: map[string]*schemaNode{}}
+	for _, opt := range opts {
+		opt(c)
+	}
+	doc := mustd.Must1(decodeValue(schema))
+	base := ""
+	if m, ok := doc.(map[string]any); ok {
+		if id, ok := m["$id"].(string); ok {
+			base = strings.TrimSuffix(id, "#")
+		}
+	}
+	c.docs[base] = doc
+	root := mustd.Must1(c.compile(base, "", doc))
+	mustd.Must0(c.resolveRefs())
+	mustd.Must0(c.checkCycles())
+	return &Schema{root: root}
+}
+
+// Validate validates the JSON-encoded data against the schema.
+// Panics with a *ValidationError listing every violation if data is invalid, or panics if data is not valid JSON.
+func (s *Schema) Validate(data []byte) {
+	v := mustd.Must1(decodeValue(data))
+	var violations []Violation
+	s.root.validate(v, "", &violations)
+	if len(violations) > 0 {
+		panic(&ValidationError{Violations: violations})
+	}
+}
+
+type schemaNode struct {
+	// location is the URI of the schema document followed by # and the JSON Pointer to the node.
+	location string
+	boolean  *bool
+
+	ref     string
+	refNode *schemaNode
+
+	types    []string
+	enum     []any
+	constant any
+	hasConst bool
+
+	minimum, maximum, exclusiveMinimum, exclusiveMaximum, multipleOf *big.Rat
+
+	minLength, maxLength *int
+	pattern              *regexp.Regexp
+
+	items       *schemaNode
+	prefixItems []*schemaNode
+	minItems    *int
+	maxItems    *int
+	uniqueItems bool
+
+	properties           map[string]*schemaNode
+	patternProperties    []patternProperty
+	additionalProperties *schemaNode
+	required             []string
+	minProperties        *int
+	maxProperties        *int
+
+	allOf, anyOf, oneOf []*schemaNode
+	not                 *schemaNode
+}
+
+type patternProperty struct {
+	pattern *regexp.Regexp
+	node    *schemaNode
+}
+
+type schemaCompiler struct {
+	loader func(uri string) []byte
+	// docs maps URIs to decoded schema documents.
+	docs map[string]any
+	// nodes maps locations to compiled nodes.
+	nodes map[string]*schemaNode
+}
+
+func (c *schemaCompiler) errorf(location, format string, a ...any) error {
+	return fmt.Errorf("jsonmust: invalid schema at %s: %s", location, fmt.Sprintf(format, a...))
+}
+
+func (c *schemaCompiler) compile(uri, pointer string, v any) (*schemaNode, error) {
+	location := uri + "#" + pointer
+	if n, ok := c.nodes[location]; ok {
+		return n, nil
+	}
+	n := &schemaNode{location: location}
+	c.nodes[location] = n
+	switch s := v.(type) {
+	case bool:
+		n.boolean = &s
+		return n, nil
+	case map[string]any:
+		return n, c.compileObject(n, uri, pointer, s)
+	default:
+		return nil, c.errorf(location, "schema must be an object or a boolean")
+	}
+}
+
+func (c *schemaCompiler) compileObject(n *schemaNode, uri, pointer string, s map[string]any) (err error) {
+	sub := func(keyword string, v any) (*schemaNode, error) {
+		return c.compile(uri, pointer+"/"+escapePointerToken(keyword), v)
+	}
+	subs := func(keyword string, v any) ([]*schemaNode, error) {
+		a, ok := v.([]any)
+		if !ok || len(a) == 0 {
+			return nil, c.errorf(n.location, "%s must be a non-empty array", keyword)
+		}
+		nodes := make([]*schemaNode, len(a))
+		for i, e := range a {
+			if nodes[i], err = c.compile(uri, pointer+"/"+keyword+"/"+strconv.Itoa(i), e); err != nil {
+				return nil, err
+			}
+		}
+		return nodes, nil
+	}
+	number := func(keyword string, v any) (*big.Rat, error) {
+		num, ok := v.(json.Number)
+		if !ok {
+			return nil, c.errorf(n.location, "%s must be a number", keyword)
+		}
+		r, ok := new(big.Rat).SetString(num.String())
+		if !ok {
+			return nil, c.errorf(n.location, "%s must be a number", keyword)
+		}
+		return r, nil
+	}
+	count := func(keyword string, v any) (*int, error) {
+		num, ok := v.(json.Number)
+		if ok {
+			// A number with a zero fractional part such as 2.0 is an integer.
+			if r, ok := new(big.Rat).SetString(num.String()); ok && r.IsInt() && r.Sign() >= 0 && r.Num().IsInt64() {
+				i := int(r.Num().Int64())
+				return &i, nil
+			}
+		}
+		return nil, c.errorf(n.location, "%s must be a non-negative integer", keyword)
+	}
+	compileRegexp := func(keyword string, v any) (*regexp.Regexp, error) {
+		p, ok := v.(string)
+		if !ok {
+			return nil, c.errorf(n.location, "%s must be a string", keyword)
+		}
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, c.errorf(n.location, "%s: %v", keyword, err)
+		}
+		return re, nil
+	}
+
+	for keyword, v := range s {
+		switch keyword {
+		case "$id":
+			if pointer != "" {
+				return c.errorf(n.location, "$id of a subschema is not supported")
+			}
+		case "$anchor", "$dynamicRef", "$dynamicAnchor", "if", "then", "else", "dependentSchemas", "dependentRequired",
+			"contains", "minContains", "maxContains", "propertyNames", "unevaluatedItems", "unevaluatedProperties":
+			return c.errorf(n.location, "%s is not supported", keyword)
+		case "$ref":
+			ref, ok := v.(string)
+			if !ok {
+				return c.errorf(n.location, "$ref must be a string")
+			}
+			if n.ref, err = resolveURI(uri, ref); err != nil {
+				return c.errorf(n.location, "$ref: %v", err)
+			}
+		case "$defs":
+			defs, ok := v.(map[string]any)
+			if !ok {
+				return c.errorf(n.location, "$defs must be an object")
+			}
+			for name, def := range defs {
+				if _, err = c.compile(uri, pointer+"/$defs/"+escapePointerToken(name), def); err != nil {
+					return err
+				}
+			}
+		case "type":
+			switch t := v.(type) {
+			case string:
+				n.types = []string{t}
+			case []any:
+				for _, e := range t {
+					s, ok := e.(string)
+					if !ok {
+						return c.errorf(n.location, "type must be a string or an array of strings")
+					}
+					n.types = append(n.types, s)
+				}
+			default:
+				return c.errorf(n.location, "type must be a string or an array of strings")
+			}
+			for _, t := range n.types {
+				if !slices.Contains([]string{"null", "boolean", "object", "array", "number", "string", "integer"}, t) {
+					return c.errorf(n.location, "unknown type %q", t)
+				}
+			}
+		case "enum":
+			a, ok := v.([]any)
+			if !ok {
+				return c.errorf(n.location, "enum must be an array")
+			}
+			n.enum = a
+		case "const":
+			n.constant, n.hasConst = v, true
+		case "minimum":
+			n.minimum, err = number(keyword, v)
+		case "maximum":
+			n.maximum, err = number(keyword, v)
+		case "exclusiveMinimum":
+			n.exclusiveMinimum, err = number(keyword, v)
+		case "exclusiveMaximum":
+			n.exclusiveMaximum, err = number(keyword, v)
+		case "multipleOf":
+			if n.multipleOf, err = number(keyword, v); err == nil && n.multipleOf.Sign() <= 0 {
+				err = c.errorf(n.location, "multipleOf must be positive")
+			}
+		case "minLength":
+			n.minLength, err = count(keyword, v)
+		case "maxLength":
+			n.maxLength, err = count(keyword, v)
+		case "pattern":
+			n.pattern, err = compileRegexp(keyword, v)
+		case "items":
+			n.items, err = sub(keyword, v)
+		case "prefixItems":
+			n.prefixItems, err = subs(keyword, v)
+		case "minItems":
+			n.minItems, err = count(keyword, v)
+		case "maxItems":
+			n.maxItems, err = count(keyword, v)
+		case "uniqueItems":
+			b, ok := v.(bool)
+			if !ok {
+				return c.errorf(n.location, "uniqueItems must be a boolean")
+			}
+			n.uniqueItems = b
+		case "properties", "patternProperties":
+			props, ok := v.(map[string]any)
+			if !ok {
+				return c.errorf(n.location, "%s must be an object", keyword)
+			}
+			for _, name := range sortedKeys(props) {
+				node, err := c.compile(uri, pointer+"/"+keyword+"/"+escapePointerToken(name), props[name])
+				if err != nil {
+					return err
+				}
+				if keyword == "properties" {
+					if n.properties == nil {
+						n.properties = map[string]*schemaNode{}
+					}
+					n.properties[name] = node
+				} else {
+					re, err := compileRegexp(keyword, name)
+					if err != nil {
+						return err
+					}
+					n.patternProperties = append(n.patternProperties, patternProperty{pattern: re, node: node})
+				}
+			}
+		case "additionalProperties":
+			n.additionalProperties, err = sub(keyword, v)
+		case "required":
+			a, ok := v.([]any)
+			if !ok {
+				return c.errorf(n.location, "required must be an array of strings")
+			}
+			for _, e := range a {
+				s, ok := e.(string)
+				if !ok {
+					return c.errorf(n.location, "required must be an array of strings")
+				}
+				n.required = append(n.required, s)
+			}
+		case "minProperties":
+			n.minProperties, err = count(keyword, v)
+		case "maxProperties":
+			n.maxProperties, err = count(keyword, v)
+		case "allOf":
+			n.allOf, err = subs(keyword, v)
+		case "anyOf":
+			n.anyOf, err = subs(keyword, v)
+		case "oneOf":
+			n.oneOf, err = subs(keyword, v)
+		case "not":
+			n.not, err = sub(keyword, v)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolveURI resolves ref against the base URI and returns it in the form URI#fragment.
+func resolveURI(base, ref string) (string, error) {
+	u, err := url.Parse(ref)
+	if err != nil {
+		return "", err
+	}
+	if base != "" {
+		b, err := url.Parse(base)
+		if err != nil {
+			return "", err
+		}
+		u = b.ResolveReference(u)
+	}
+	fragment := u.Fragment
+	u.Fragment = ""
+	return u.String() + "#" + fragment, nil
+}
+
+// resolveRefs resolves $ref of all nodes, loading and compiling other documents as needed.
+func (c *schemaCompiler) resolveRefs() error {
+	for {
+		var unresolved []*schemaNode
+		for _, n := range c.nodes {
+			if n.ref != "" && n.refNode == nil {
+				unresolved = append(unresolved, n)
+			}
+		}
+		if len(unresolved) == 0 {
+			return nil
+		}
+		for _, n := range unresolved {
+			target, err := c.resolveRef(n)
+			if err != nil {
+				return err
+			}
+			n.refNode = target
+		}
+	}
+}
+
+// checkCycles reports a cycle of $ref and the combinators, which would apply schemas to the same value infinitely.
+func (c *schemaCompiler) checkCycles() error {
+	const visiting, visited = 1, 2
+	state := map[*schemaNode]int{}
+	var visit func(n *schemaNode) error
+	visit = func(n *schemaNode) error {
+		switch state[n] {
+		case visiting:
+			return c.errorf(n.location, "$ref cycle applies the schema to the same value infinitely")
+		case visited:
+			return nil
+		}
+		state[n] = visiting
+		next := slices.Concat([]*schemaNode{n.refNode, n.not}, n.allOf, n.anyOf, n.oneOf)
+		for _, m := range next {
+			if m == nil {
+				continue
+			}
+			if err := visit(m); err != nil {
+				return err
+			}
+		}
+		state[n] = visited
+		return nil
+	}
+	for _, location := range slices.Sorted(maps.Keys(c.nodes)) {
+		if err := visit(c.nodes[location]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *schemaCompiler) resolveRef(n *schemaNode) (*schemaNode, error) {
+	uri, fragment, _ := strings.Cut(n.ref, "#")
+	doc, ok := c.docs[uri]
+	if !ok {
+		if c.loader == nil {
+			return nil, c.errorf(n.location, "$ref to other document %q is not allowed without a schema loader", uri)
+		}
+		var err error
+		if doc, err = decodeValue(c.loader(uri)); err != nil {
+			return nil, c.errorf(n.location, "$ref to %q: %v", uri, err)
+		}
+		c.docs[uri] = doc
+	}
+	if fragment != "" && !strings.HasPrefix(fragment, "/") {
+		return nil, c.errorf(n.location, "$ref fragment %q must be a JSON Pointer", fragment)
+	}
+	tokens, err := parsePointer(fragment)
+	if err != nil {
+		return nil, c.errorf(n.location, "$ref: %v", err)
+	}
+	target, err := resolve(doc, tokens)
+	if err != nil {
+		return nil, c.errorf(n.location, "$ref %q cannot be resolved: %v", n.ref, err)
+	}
+	return c.compile(uri, fragment, target)
+}
+
+func (n *schemaNode) keywordLocation(keyword string) string {
+	location := strings.TrimPrefix(n.location, "#")
+	return location + "/" + keyword
+}
+
+func (n *schemaNode) isValid(v any, instance string) bool {
+	var violations []Violation
+	n.validate(v, instance, &violations)
+	return len(violations) == 0
+}
+
+func (n *schemaNode) validate(v any, instance string, violations *[]Violation) {
+	report := func(keyword, format string, a ...any) {
+		*violations = append(*violations, Violation{
+			InstanceLocation: instance,
+			KeywordLocation:  n.keywordLocation(keyword),
+			Message:          fmt.Sprintf(format, a...),
+		})
+	}
+	if n.boolean != nil {
+		if !*n.boolean {
+			*violations = append(*violations, Violation{InstanceLocation: instance, KeywordLocation: strings.TrimPrefix(n.location, "#"), Message: "no value is allowed"})
+		}
+		return
+	}
+	if n.refNode != nil {
+		n.refNode.validate(v, instance, violations)
+	}
+	if len(n.types) > 0 && !slices.ContainsFunc(n.types, func(t string) bool { return hasType(v, t) }) {
+		report("type", "expected %s, but got %s", strings.Join(n.types, " or "), kindOfValue(v))
+	}
+	if n.enum != nil && !slices.ContainsFunc(n.enum, func(e any) bool { return equalValues(v, e) }) {
+		report("enum", "value must be one of %s", Marshal(n.enum))
+	}
+	if n.hasConst && !equalValues(v, n.constant) {
+		report("const", "value must be %s", Marshal(n.constant))
+	}
+
+	switch v := v.(type) {
+	case json.Number:
+		n.validateNumber(v, report)
+	case string:
+		length := utf8.RuneCountInString(v)
+		if n.minLength != nil && length < *n.minLength {
+			report("minLength", "length must be >= %d, but got %d", *n.minLength, length)
+		}
+		if n.maxLength != nil && length > *n.maxLength {
+			report("maxLength", "length must be <= %d, but got %d", *n.maxLength, length)
+		}
+		if n.pattern != nil && !n.pattern.MatchString(v) {
+			report("pattern", "value must match %q", n.pattern.String())
+		}
+	case []any:
+		n.validateArray(v, instance, violations, report)
+	case map[string]any:
+		n.validateObject(v, instance, violations, report)
+	}
+
+	for _, s := range n.allOf {
+		s.validate(v, instance, violations)
+	}
+	if n.anyOf != nil && !slices.ContainsFunc(n.anyOf, func(s *schemaNode) bool { return s.isValid(v, instance) }) {
+		report("anyOf", "value must match at least one schema")
+	}
+	if n.oneOf != nil {
+		matched := 0
+		for _, s := range n.oneOf {
+			if s.isValid(v, instance) {
+				matched++
+			}
+		}
+		if matched != 1 {
+			report("oneOf", "value must match exactly one schema, but matched %d", matched)
+		}
+	}
+	if n.not != nil && n.not.isValid(v, instance) {
+		report("not", "value must not match the schema")
+	}
+}
+
+func (n *schemaNode) validateNumber(v json.Number, report func(keyword, format string, a ...any)) {
+	r, ok := new(big.Rat).SetString(v.String())
+	if !ok {
+		return
+	}
+	if n.minimum != nil && r.Cmp(n.minimum) < 0 {
+		report("minimum", "value must be >= %s, but got %s", formatRat(n.minimum), v)
+	}
+	if n.maximum != nil && r.Cmp(n.maximum) > 0 {
+		report("maximum", "value must be <= %s, but got %s", formatRat(n.maximum), v)
+	}
+	if n.exclusiveMinimum != nil && r.Cmp(n.exclusiveMinimum) <= 0 {
+		report("exclusiveMinimum", "value must be > %s, but got %s", formatRat(n.exclusiveMinimum), v)
+	}
+	if n.exclusiveMaximum != nil && r.Cmp(n.exclusiveMaximum) >= 0 {
+		report("exclusiveMaximum", "value must be < %s, but got %s", formatRat(n.exclusiveMaximum), v)
+	}
+	if n.multipleOf != nil && !new(big.Rat).Quo(r, n.multipleOf).IsInt() {
+		report("multipleOf", "value must be a multiple of %s, but got %s", formatRat(n.multipleOf), v)
+	}
+}
+
+func (n *schemaNode) validateArray(v []any, instance string, violations *[]Violation, report func(keyword, format string, a ...any)) {
+	if n.minItems != nil && len(v) < *n.minItems {
+		report("minItems", "array must have >= %d items, but has %d", *n.minItems, len(v))
+	}
+	if n.maxItems != nil && len(v) > *n.maxItems {
+		report("maxItems", "array must have <= %d items, but has %d", *n.maxItems, len(v))
+	}
+	if n.uniqueItems {
+		for i := range v {
+			for j := range i {
+				if equalValues(v[i], v[j]) {
+					report("uniqueItems", "items at %d and %d must be unique", j, i)
+				}
+			}
+		}
+	}
+	for i, e := range v {
+		at := instance + "/" + strconv.Itoa(i)
+		if i < len(n.prefixItems) {
+			n.prefixItems[i].validate(e, at, violations)
+		} else if n.items != nil {
+			n.items.validate(e, at, violations)
+		}
+	}
+}
+
+func (n *schemaNode) validateObject(v map[string]any, instance string, violations *[]Violation, report func(keyword, format string, a ...any)) {
+	for _, name := range n.required {
+		if _, ok := v[name]; !ok {
+			report("required", "missing required property %q", name)
+		}
+	}
+	if n.minProperties != nil && len(v) < *n.minProperties {
+		report("minProperties", "object must have >= %d properties, but has %d", *n.minProperties, len(v))
+	}
+	if n.maxProperties != nil && len(v) > *n.maxProperties {
+		report("maxProperties", "object must have <= %d properties, but has %d", *n.maxProperties, len(v))
+	}
+	for _, name := range sortedKeys(v) {
+		at := instance + "/" + escapePointerToken(name)
+		matched := false
+		if s, ok := n.properties[name]; ok {
+			s.validate(v[name], at, violations)
+			matched = true
+		}
+		for _, p := range n.patternProperties {
+			if p.pattern.MatchString(name) {
+				p.node.validate(v[name], at, violations)
+				matched = true
+			}
+		}
+		if !matched && n.additionalProperties != nil {
+			n.additionalProperties.validate(v[name], at, violations)
+		}
+	}
+}
+
+// formatRat formats r as a decimal number.
+func formatRat(r *big.Rat) string {
+	if r.IsInt() {
+		return r.RatString()
+	}
+	return strings.TrimRight(r.FloatString(20), "0")
+}
+
+func hasType(v any, t string) bool {
+	switch v := v.(type) {
+	case nil:
+		return t == "null"
+	case bool:
+		return t == "boolean"
+	case string:
+		return t == "string"
+	case []any:
+		return t == "array"
+	case map[string]any:
+		return t == "object"
+	case json.Number:
+		if t == "number" {
+			return true
+		}
+		if t == "integer" {
+			r, ok := new(big.Rat).SetString(v.String())
+			return ok && r.IsInt()
+		}
+	}
+	return false
+}
@@ -0,0 +1,10 @@
+module github.com/Jumpaku/go-mustd/encodingmust/tomlmust
+
+go 1.25.5
+
+require (
+	github.com/Jumpaku/go-mustd v0.0.0
+	github.com/pelletier/go-toml/v2 v2.2.4
+)
+
+replace github.com/Jumpaku/go-mustd => ../..
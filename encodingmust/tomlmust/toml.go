@@ -0,0 +1,114 @@
+// Package tomlmust provides wrappers for the github.com/pelletier/go-toml/v2 package with panicking error handling.
+//
+// It is a separate module so that the core module stays free of third-party dependencies.
+package tomlmust
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"github.com/Jumpaku/go-mustd"
+	"github.com/Jumpaku/go-mustd/encodingmust"
+	"github.com/Jumpaku/go-mustd/iomust"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// Marshal returns the TOML encoding of v. Panics if an error occurs.
+func Marshal(v any) []byte {
+	return mustd.Must1(toml.Marshal(v))
+}
+
+// Unmarshal parses the TOML-encoded data and stores the result in v. Panics if an error occurs.
+// Syntax and type errors are reported as *encodingmust.DecodeError with the line, the column and an excerpt of data.
+func Unmarshal(data []byte, v any) {
+	mustd.Must0(locateError(data, toml.Unmarshal(data, v)))
+}
+
+// UnmarshalAs parses the TOML-encoded data and returns the result as a value of type T. Panics if an error occurs.
+func UnmarshalAs[T any](data []byte) T {
+	var v T
+	Unmarshal(data, &v)
+	return v
+}
+
+// locateError returns err as *encodingmust.DecodeError locating it in data if err is a decode error of the toml package.
+// For a strict mode error, the first missing key is located.
+func locateError(data []byte, err error) error {
+	var decodeErr *toml.DecodeError
+	var strictErr *toml.StrictMissingError
+	switch {
+	case errors.As(err, &decodeErr):
+	case errors.As(err, &strictErr) && len(strictErr.Errors) > 0:
+		decodeErr = &strictErr.Errors[0]
+	default:
+		return err
+	}
+	line, column := decodeErr.Position()
+	return encodingmust.NewDecodeErrorAt(data, line, column, err)
+}
+
+// Decoder wraps toml.Decoder and provides panicking error handling.
+// A TOML input is a single document, so Decode reads the whole input.
+type Decoder struct {
+	reader                iomust.Reader
+	disallowUnknownFields bool
+}
+
+// NewDecoder returns a new decoder that reads from r.
+func NewDecoder(r iomust.Reader) *Decoder {
+	return &Decoder{reader: r}
+}
+
+// DisallowUnknownFields causes the Decoder to panic when the input contains keys which do not match any fields of the destination struct.
+func (dec *Decoder) DisallowUnknownFields() {
+	dec.disallowUnknownFields = true
+}
+
+// Decode reads the whole input and stores the result in v. Panics if an error occurs.
+// Syntax and type errors are reported as *encodingmust.DecodeError with the line, the column and an excerpt of the input.
+func (dec *Decoder) Decode(v any) {
+	data := mustd.Must1(io.ReadAll(dec.reader.Reader()))
+	d := toml.NewDecoder(bytes.NewReader(data))
+	if dec.disallowUnknownFields {
+		d.DisallowUnknownFields()
+	}
+	mustd.Must0(locateError(data, d.Decode(v)))
+}
+
+// DecodeAs reads the whole input of dec and returns it as a value of type T. Panics if an error occurs.
+func DecodeAs[T any](dec *Decoder) T {
+	var v T
+	dec.Decode(&v)
+	return v
+}
+
+// Encoder wraps toml.Encoder and provides panicking error handling.
+type Encoder struct {
+	encoder *toml.Encoder
+}
+
+// NewEncoder returns a new encoder that writes to w.
+func NewEncoder(w iomust.Writer) *Encoder {
+	return &Encoder{encoder: toml.NewEncoder(w.Writer())}
+}
+
+// Encode writes the TOML encoding of v. Panics if an error occurs.
+func (enc *Encoder) Encode(v any) {
+	mustd.Must0(enc.encoder.Encode(v))
+}
+
+// SetIndentTables forces the encoder to indent tables and array tables.
+func (enc *Encoder) SetIndentTables(indent bool) {
+	enc.encoder.SetIndentTables(indent)
+}
+
+// SetArraysMultiline forces the encoder to emit all arrays with one element per line.
+func (enc *Encoder) SetArraysMultiline(multiline bool) {
+	enc.encoder.SetArraysMultiline(multiline)
+}
+
+// SetTablesInline forces the encoder to emit all tables inline.
+func (enc *Encoder) SetTablesInline(inline bool) {
+	enc.encoder.SetTablesInline(inline)
+}
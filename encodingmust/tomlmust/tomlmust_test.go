@@ -0,0 +1,95 @@
+package tomlmust_test
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Jumpaku/go-mustd/encodingmust"
+	"github.com/Jumpaku/go-mustd/encodingmust/tomlmust"
+	"github.com/Jumpaku/go-mustd/iomust"
+)
+
+type config struct {
+	Name   string `toml:"name"`
+	Server struct {
+		Host string `toml:"host"`
+		Port int    `toml:"port"`
+	} `toml:"server"`
+	Tags []string `toml:"tags"`
+}
+
+const configTOML = `name = "tool"
+tags = ["a", "b"]
+
+[server]
+host = "localhost"
+port = 8080
+`
+
+func TestMarshalUnmarshal(t *testing.T) {
+	t.Run("UnmarshalAs", func(t *testing.T) {
+		c := tomlmust.UnmarshalAs[config]([]byte(configTOML))
+		if c.Name != "tool" || c.Server.Port != 8080 || !reflect.DeepEqual(c.Tags, []string{"a", "b"}) {
+			t.Errorf("unexpected config %+v", c)
+		}
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		c := tomlmust.UnmarshalAs[config]([]byte(configTOML))
+		var got config
+		tomlmust.Unmarshal(tomlmust.Marshal(c), &got)
+		if !reflect.DeepEqual(got, c) {
+			t.Errorf("expected %+v, got %+v", c, got)
+		}
+	})
+
+	t.Run("type error location", func(t *testing.T) {
+		defer func() {
+			err, _ := recover().(error)
+			var decodeErr *encodingmust.DecodeError
+			if !errors.As(err, &decodeErr) {
+				t.Fatalf("expected *encodingmust.DecodeError, got %v", err)
+			}
+			if decodeErr.Line != 6 || decodeErr.Column != 8 || decodeErr.Excerpt != "port = \"http\"\n       ^" {
+				t.Errorf("unexpected location line %d, column %d, excerpt %q", decodeErr.Line, decodeErr.Column, decodeErr.Excerpt)
+			}
+		}()
+		tomlmust.UnmarshalAs[config]([]byte(strings.Replace(configTOML, "8080", `"http"`, 1)))
+	})
+}
+
+func TestDecoder(t *testing.T) {
+	t.Run("DecodeAs", func(t *testing.T) {
+		dec := tomlmust.NewDecoder(iomust.ReaderOf(strings.NewReader(configTOML)))
+		if c := tomlmust.DecodeAs[config](dec); c.Server.Host != "localhost" {
+			t.Errorf("unexpected config %+v", c)
+		}
+	})
+
+	t.Run("DisallowUnknownFields", func(t *testing.T) {
+		dec := tomlmust.NewDecoder(iomust.ReaderOf(strings.NewReader(configTOML + "debug = true\n")))
+		dec.DisallowUnknownFields()
+		defer func() {
+			err, _ := recover().(error)
+			var decodeErr *encodingmust.DecodeError
+			if !errors.As(err, &decodeErr) || decodeErr.Line != 7 {
+				t.Errorf("expected *encodingmust.DecodeError on line 7, got %v", err)
+			}
+		}()
+		tomlmust.DecodeAs[config](dec)
+	})
+}
+
+func TestEncoder(t *testing.T) {
+	buf := &bytes.Buffer{}
+	enc := tomlmust.NewEncoder(iomust.WriterOf(buf))
+	enc.SetIndentTables(true)
+	enc.Encode(map[string]any{"server": map[string]any{"port": 8080}})
+	expected := "[server]\n  port = 8080\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+}
@@ -0,0 +1,171 @@
+// Package xmlmust provides wrappers for the encoding/xml package with panicking error handling.
+package xmlmust
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"iter"
+	"strconv"
+
+	"github.com/Jumpaku/go-mustd"
+	"github.com/Jumpaku/go-mustd/encodingmust"
+	"github.com/Jumpaku/go-mustd/iomust"
+)
+
+// Marshal returns the XML encoding of v. Panics if an error occurs.
+func Marshal(v any) []byte {
+	return mustd.Must1(xml.Marshal(v))
+}
+
+// MarshalIndent is like Marshal but applies Indent to format the output. Panics if an error occurs.
+func MarshalIndent(v any, prefix, indent string) []byte {
+	return mustd.Must1(xml.MarshalIndent(v, prefix, indent))
+}
+
+// Unmarshal parses the XML-encoded data and stores the result in v. Panics if an error occurs.
+// Syntax and conversion errors are reported as *encodingmust.DecodeError with the line, the column and an excerpt of data.
+func Unmarshal(data []byte, v any) {
+	NewDecoder(iomust.ReaderOf(bytes.NewReader(data))).Decode(v)
+}
+
+// UnmarshalAs parses the XML-encoded data and returns the result as a value of type T. Panics if an error occurs.
+func UnmarshalAs[T any](data []byte) T {
+	var v T
+	Unmarshal(data, &v)
+	return v
+}
+
+// Decoder wraps xml.Decoder and provides panicking error handling.
+// Syntax and conversion errors are reported as *encodingmust.DecodeError with the line, the column and an excerpt of the input.
+type Decoder struct {
+	*xml.Decoder
+	position *encodingmust.PositionReader
+}
+
+// NewDecoder returns a new decoder that reads from r.
+func NewDecoder(r iomust.Reader) *Decoder {
+	position := encodingmust.NewPositionReader(r.Reader())
+	return &Decoder{Decoder: xml.NewDecoder(position), position: position}
+}
+
+// locate returns err with the current position of the decoder if err is a syntax or conversion error.
+func (d *Decoder) locate(err error) error {
+	var syntaxErr *xml.SyntaxError
+	var unmarshalErr xml.UnmarshalError
+	var numErr *strconv.NumError
+	if !errors.As(err, &syntaxErr) && !errors.As(err, &unmarshalErr) && !errors.As(err, &numErr) {
+		return err
+	}
+	line, column := d.InputPos()
+	return d.position.DecodeErrorAt(line, column, err)
+}
+
+// Decode reads the next XML element and stores the result in v. Panics if an error occurs.
+func (d *Decoder) Decode(v any) {
+	mustd.Must0(d.locate(d.Decoder.Decode(v)))
+}
+
+// DecodeElement reads the XML element starting with start and stores the result in v. Panics if an error occurs.
+func (d *Decoder) DecodeElement(v any, start *xml.StartElement) {
+	mustd.Must0(d.locate(d.Decoder.DecodeElement(v, start)))
+}
+
+// Token returns the next XML token in the input stream, or nil at the end of the input. Panics if an error occurs.
+func (d *Decoder) Token() xml.Token {
+	token, err := d.Decoder.Token()
+	if err == io.EOF {
+		return nil
+	}
+	mustd.Must0(d.locate(err))
+	return token
+}
+
+// RawToken is like Token but does not verify that start and end elements match and does not translate name space prefixes.
+// It returns nil at the end of the input. Panics if an error occurs.
+func (d *Decoder) RawToken() xml.Token {
+	token, err := d.Decoder.RawToken()
+	if err == io.EOF {
+		return nil
+	}
+	mustd.Must0(d.locate(err))
+	return token
+}
+
+// Skip reads tokens until it has consumed the end element matching the most recent start element already consumed. Panics if an error occurs.
+func (d *Decoder) Skip() {
+	mustd.Must0(d.locate(d.Decoder.Skip()))
+}
+
+// StartElements returns an iterator over the remaining start elements whose local name is local, reading tokens as needed.
+// If the loop body does not consume the element by DecodeElement or Skip, start elements nested in it are also yielded.
+// Panics as Token during iteration.
+func (d *Decoder) StartElements(local string) iter.Seq[xml.StartElement] {
+	return func(yield func(xml.StartElement) bool) {
+		for {
+			token := d.Token()
+			if token == nil {
+				return
+			}
+			start, ok := token.(xml.StartElement)
+			if ok && start.Name.Local == local && !yield(start.Copy()) {
+				return
+			}
+		}
+	}
+}
+
+// ElementsAs returns an iterator over the remaining elements of d whose local name is local, decoded as values of type T.
+// Panics as DecodeElement during iteration.
+func ElementsAs[T any](d *Decoder, local string) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for start := range d.StartElements(local) {
+			var v T
+			d.DecodeElement(&v, &start)
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// Encoder wraps xml.Encoder and provides panicking error handling.
+type Encoder struct {
+	encoder *xml.Encoder
+}
+
+// NewEncoder returns a new encoder that writes to w.
+func NewEncoder(w iomust.Writer) *Encoder {
+	return &Encoder{encoder: xml.NewEncoder(w.Writer())}
+}
+
+// Close flushes the encoder and checks that all elements are closed. It does not close the underlying writer. Panics if an error occurs.
+func (enc *Encoder) Close() {
+	mustd.Must0(enc.encoder.Close())
+}
+
+// Encode writes the XML encoding of v. Panics if an error occurs.
+func (enc *Encoder) Encode(v any) {
+	mustd.Must0(enc.encoder.Encode(v))
+}
+
+// EncodeElement writes the XML encoding of v using start as the outermost tag. Panics if an error occurs.
+func (enc *Encoder) EncodeElement(v any, start xml.StartElement) {
+	mustd.Must0(enc.encoder.EncodeElement(v, start))
+}
+
+// EncodeToken writes the given XML token. Call Flush to write the buffered output. Panics if an error occurs.
+func (enc *Encoder) EncodeToken(t xml.Token) {
+	mustd.Must0(enc.encoder.EncodeToken(t))
+}
+
+// Flush flushes any buffered XML to the underlying writer. Panics if an error occurs.
+func (enc *Encoder) Flush() {
+	mustd.Must0(enc.encoder.Flush())
+}
+
+// Indent sets the encoder to generate XML in which each element begins on a new indented line.
+func (enc *Encoder) Indent(prefix, indent string) {
+	enc.encoder.Indent(prefix, indent)
+}
@@ -0,0 +1,197 @@
+package xmlmust_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Jumpaku/go-mustd/encodingmust"
+	"github.com/Jumpaku/go-mustd/encodingmust/xmlmust"
+	"github.com/Jumpaku/go-mustd/iomust"
+)
+
+type dependency struct {
+	GroupID    string `xml:"groupId"`
+	ArtifactID string `xml:"artifactId"`
+	Version    string `xml:"version"`
+}
+
+type project struct {
+	XMLName      xml.Name     `xml:"project"`
+	ArtifactID   string       `xml:"artifactId"`
+	Dependencies []dependency `xml:"dependencies>dependency"`
+}
+
+const pom = `<?xml version="1.0" encoding="UTF-8"?>
+<project>
+  <artifactId>app</artifactId>
+  <dependencies>
+    <dependency>
+      <groupId>junit</groupId>
+      <artifactId>junit</artifactId>
+      <version>4.13.2</version>
+    </dependency>
+  </dependencies>
+</project>
+`
+
+func TestMarshalUnmarshal(t *testing.T) {
+	t.Run("UnmarshalAs", func(t *testing.T) {
+		got := xmlmust.UnmarshalAs[project]([]byte(pom))
+		want := []dependency{{GroupID: "junit", ArtifactID: "junit", Version: "4.13.2"}}
+		if got.ArtifactID != "app" || !reflect.DeepEqual(got.Dependencies, want) {
+			t.Errorf("unexpected project %+v", got)
+		}
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		p := project{ArtifactID: "app", Dependencies: []dependency{{GroupID: "g", ArtifactID: "a", Version: "1"}}}
+		var got project
+		xmlmust.Unmarshal(xmlmust.Marshal(p), &got)
+		got.XMLName = xml.Name{}
+		if !reflect.DeepEqual(got, p) {
+			t.Errorf("expected %+v, got %+v", p, got)
+		}
+		indented := string(xmlmust.MarshalIndent(p, "", "  "))
+		if !strings.Contains(indented, "\n  <artifactId>app</artifactId>") {
+			t.Errorf("unexpected indented output %q", indented)
+		}
+	})
+
+	t.Run("Marshal unsupported type panics", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("Marshal did not panic")
+			}
+		}()
+		xmlmust.Marshal(map[string]string{"a": "b"})
+	})
+
+	t.Run("syntax error location", func(t *testing.T) {
+		defer func() {
+			err, _ := recover().(error)
+			var decodeErr *encodingmust.DecodeError
+			if !errors.As(err, &decodeErr) || decodeErr.Line != 3 {
+				t.Fatalf("expected *encodingmust.DecodeError on line 3, got %v", err)
+			}
+			var syntaxErr *xml.SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Errorf("expected *xml.SyntaxError, got %v", decodeErr.Err)
+			}
+		}()
+		xmlmust.UnmarshalAs[project]([]byte("<project>\n  <artifactId>app</artifactId>\n  <dependencies></dependency>\n</project>"))
+	})
+}
+
+type testCase struct {
+	Name    string   `xml:"name,attr"`
+	Failure *failure `xml:"failure"`
+}
+
+type failure struct {
+	Message string `xml:"message,attr"`
+}
+
+const junitReport = `<testsuites>
+  <testsuite name="a">
+    <testcase name="ok"/>
+    <testcase name="broken"><failure message="expected 1"/></testcase>
+  </testsuite>
+  <testsuite name="b">
+    <testcase name="ok too"/>
+  </testsuite>
+</testsuites>`
+
+func TestDecoder(t *testing.T) {
+	t.Run("Token returns nil at the end", func(t *testing.T) {
+		d := xmlmust.NewDecoder(iomust.ReaderOf(strings.NewReader("<a>text</a>")))
+		count := 0
+		for d.Token() != nil {
+			count++
+		}
+		if count != 3 {
+			t.Errorf("expected 3 tokens, got %d", count)
+		}
+	})
+
+	t.Run("StartElements", func(t *testing.T) {
+		d := xmlmust.NewDecoder(iomust.ReaderOf(strings.NewReader(junitReport)))
+		var suites []string
+		for start := range d.StartElements("testsuite") {
+			suites = append(suites, start.Attr[0].Value)
+			d.Skip()
+		}
+		if !reflect.DeepEqual(suites, []string{"a", "b"}) {
+			t.Errorf("unexpected suites %v", suites)
+		}
+	})
+
+	t.Run("ElementsAs", func(t *testing.T) {
+		d := xmlmust.NewDecoder(iomust.ReaderOf(strings.NewReader(junitReport)))
+		var names []string
+		var failures []string
+		for tc := range xmlmust.ElementsAs[testCase](d, "testcase") {
+			names = append(names, tc.Name)
+			if tc.Failure != nil {
+				failures = append(failures, tc.Failure.Message)
+			}
+		}
+		if !reflect.DeepEqual(names, []string{"ok", "broken", "ok too"}) || !reflect.DeepEqual(failures, []string{"expected 1"}) {
+			t.Errorf("unexpected test cases %v with failures %v", names, failures)
+		}
+	})
+
+	t.Run("conversion error location", func(t *testing.T) {
+		defer func() {
+			err, _ := recover().(error)
+			var decodeErr *encodingmust.DecodeError
+			if !errors.As(err, &decodeErr) || decodeErr.Line != 2 {
+				t.Errorf("expected *encodingmust.DecodeError on line 2, got %v", err)
+			}
+		}()
+		var v struct {
+			Count int `xml:"count"`
+		}
+		d := xmlmust.NewDecoder(iomust.ReaderOf(strings.NewReader("<r>\n<count>many</count>\n</r>")))
+		d.Decode(&v)
+	})
+}
+
+func TestEncoder(t *testing.T) {
+	t.Run("Encode", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		enc := xmlmust.NewEncoder(iomust.WriterOf(buf))
+		enc.Encode(dependency{GroupID: "g", ArtifactID: "a", Version: "1"})
+		expected := "<dependency><groupId>g</groupId><artifactId>a</artifactId><version>1</version></dependency>"
+		if buf.String() != expected {
+			t.Errorf("expected %q, got %q", expected, buf.String())
+		}
+	})
+
+	t.Run("EncodeToken and Flush", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		enc := xmlmust.NewEncoder(iomust.WriterOf(buf))
+		start := xml.StartElement{Name: xml.Name{Local: "a"}}
+		enc.EncodeToken(start)
+		enc.EncodeToken(xml.CharData("text"))
+		enc.EncodeToken(start.End())
+		enc.Flush()
+		if buf.String() != "<a>text</a>" {
+			t.Errorf("expected %q, got %q", "<a>text</a>", buf.String())
+		}
+	})
+
+	t.Run("EncodeToken mismatched end panics", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("EncodeToken did not panic")
+			}
+		}()
+		enc := xmlmust.NewEncoder(iomust.WriterOf(&bytes.Buffer{}))
+		enc.EncodeToken(xml.StartElement{Name: xml.Name{Local: "a"}})
+		enc.EncodeToken(xml.EndElement{Name: xml.Name{Local: "b"}})
+	})
+}
@@ -0,0 +1,10 @@
+module github.com/Jumpaku/go-mustd/encodingmust/yamlmust
+
+go 1.25.5
+
+require (
+	github.com/Jumpaku/go-mustd v0.0.0
+	go.yaml.in/yaml/v3 v3.0.4
+)
+
+replace github.com/Jumpaku/go-mustd => ../..
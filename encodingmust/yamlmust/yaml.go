@@ -0,0 +1,152 @@
+// Package yamlmust provides wrappers for the go.yaml.in/yaml/v3 package with panicking error handling.
+//
+// It is a separate module so that the core module stays free of third-party dependencies.
+package yamlmust
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"iter"
+	"strconv"
+	"strings"
+
+	"github.com/Jumpaku/go-mustd"
+	"github.com/Jumpaku/go-mustd/encodingmust"
+	"github.com/Jumpaku/go-mustd/iomust"
+	"go.yaml.in/yaml/v3"
+)
+
+// Marshal returns the YAML encoding of v. Panics if an error occurs.
+func Marshal(v any) []byte {
+	return mustd.Must1(yaml.Marshal(v))
+}
+
+// Unmarshal parses the first YAML document in data and stores the result in v. Panics if an error occurs.
+// Syntax and type errors are reported as *encodingmust.DecodeError with the line and an excerpt of data, and without the column, which the yaml package does not report.
+func Unmarshal(data []byte, v any) {
+	err := yaml.Unmarshal(data, v)
+	mustd.Must0(locateError(err, func(line int, err error) error {
+		return encodingmust.NewDecodeErrorAt(data, line, 0, err)
+	}))
+}
+
+// UnmarshalAs parses the first YAML document in data and returns the result as a value of type T. Panics if an error occurs.
+func UnmarshalAs[T any](data []byte) T {
+	var v T
+	Unmarshal(data, &v)
+	return v
+}
+
+// locateError returns the result of locate applied to err and its line if err is a syntax error or a *yaml.TypeError.
+// The yaml package exposes the line only as the "line N: " prefix of the messages of these errors, and does not report the column.
+func locateError(err error, locate func(line int, err error) error) error {
+	if err == nil || errors.Is(err, io.EOF) {
+		return err
+	}
+	var typeErr *yaml.TypeError
+	message, ok := strings.CutPrefix(err.Error(), "yaml: ")
+	if errors.As(err, &typeErr) && len(typeErr.Errors) > 0 {
+		message, ok = typeErr.Errors[0], true
+	}
+	if !ok {
+		return err
+	}
+	rest, ok := strings.CutPrefix(message, "line ")
+	number, _, found := strings.Cut(rest, ": ")
+	line, convErr := strconv.Atoi(number)
+	if !ok || !found || convErr != nil || line <= 0 {
+		return err
+	}
+	return locate(line, err)
+}
+
+// Decoder wraps yaml.Decoder and provides panicking error handling.
+// Syntax and type errors are reported as *encodingmust.DecodeError with the line and an excerpt of the input, and without the column.
+type Decoder struct {
+	decoder  *yaml.Decoder
+	position *encodingmust.PositionReader
+}
+
+// NewDecoder returns a new decoder that reads from r.
+func NewDecoder(r iomust.Reader) *Decoder {
+	position := encodingmust.NewPositionReader(r.Reader())
+	return &Decoder{decoder: yaml.NewDecoder(position), position: position}
+}
+
+func (dec *Decoder) decode(v any) error {
+	return locateError(dec.decoder.Decode(v), func(line int, err error) error {
+		return dec.position.DecodeErrorAt(line, 0, err)
+	})
+}
+
+// Decode reads the next YAML document from its input and stores it in v. Panics if an error occurs, including io.EOF at the end of the input.
+func (dec *Decoder) Decode(v any) {
+	mustd.Must0(dec.decode(v))
+}
+
+// KnownFields ensures that the keys in decoded mappings exist as fields in the struct being decoded into.
+func (dec *Decoder) KnownFields(enable bool) {
+	dec.decoder.KnownFields(enable)
+}
+
+// DecodeAs reads the next YAML document from the input of dec and returns it as a value of type T. Panics if an error occurs.
+func DecodeAs[T any](dec *Decoder) T {
+	var v T
+	dec.Decode(&v)
+	return v
+}
+
+// DocumentsAs returns an iterator over the remaining YAML documents of dec decoded as values of type T.
+// Panics if an error occurs during iteration.
+func DocumentsAs[T any](dec *Decoder) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for {
+			var v T
+			err := dec.decode(&v)
+			if err == io.EOF {
+				return
+			}
+			mustd.Must0(err)
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// Encoder wraps yaml.Encoder and provides panicking error handling.
+type Encoder struct {
+	encoder *yaml.Encoder
+}
+
+// NewEncoder returns a new encoder that writes to w. Close must be called to flush the output.
+func NewEncoder(w iomust.Writer) *Encoder {
+	return &Encoder{encoder: yaml.NewEncoder(w.Writer())}
+}
+
+// Encode writes the YAML encoding of v as a document, separated from the previous one by "---". Panics if an error occurs.
+func (enc *Encoder) Encode(v any) {
+	mustd.Must0(enc.encoder.Encode(v))
+}
+
+// SetIndent sets the number of spaces used for indentation.
+func (enc *Encoder) SetIndent(spaces int) {
+	enc.encoder.SetIndent(spaces)
+}
+
+// Close flushes any buffered output. It does not close the underlying writer. Panics if an error occurs.
+func (enc *Encoder) Close() {
+	mustd.Must0(enc.encoder.Close())
+}
+
+// MarshalDocuments returns the YAML encoding of values as a multi-document stream. Panics if an error occurs.
+func MarshalDocuments[T any](values iter.Seq[T]) []byte {
+	var buf bytes.Buffer
+	enc := NewEncoder(iomust.WriterOf(&buf))
+	for v := range values {
+		enc.Encode(v)
+	}
+	enc.Close()
+	return buf.Bytes()
+}
@@ -0,0 +1,149 @@
+package yamlmust_test
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Jumpaku/go-mustd/encodingmust"
+	"github.com/Jumpaku/go-mustd/encodingmust/yamlmust"
+	"github.com/Jumpaku/go-mustd/iomust"
+)
+
+type manifest struct {
+	Kind     string `yaml:"kind"`
+	Metadata struct {
+		Name string `yaml:"name"`
+	} `yaml:"metadata"`
+	Replicas int `yaml:"replicas,omitempty"`
+}
+
+const manifests = `kind: Deployment
+metadata:
+  name: web
+replicas: 3
+---
+kind: Service
+metadata:
+  name: web
+`
+
+func TestMarshalUnmarshal(t *testing.T) {
+	t.Run("UnmarshalAs", func(t *testing.T) {
+		m := yamlmust.UnmarshalAs[manifest]([]byte(manifests))
+		if m.Kind != "Deployment" || m.Metadata.Name != "web" || m.Replicas != 3 {
+			t.Errorf("unexpected manifest %+v", m)
+		}
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		var m manifest
+		m.Kind = "ConfigMap"
+		m.Metadata.Name = "settings"
+		data := yamlmust.Marshal(m)
+		if string(data) != "kind: ConfigMap\nmetadata:\n    name: settings\n" {
+			t.Errorf("unexpected YAML %q", data)
+		}
+		var got manifest
+		yamlmust.Unmarshal(data, &got)
+		if !reflect.DeepEqual(got, m) {
+			t.Errorf("expected %+v, got %+v", m, got)
+		}
+	})
+
+	t.Run("type error location", func(t *testing.T) {
+		defer func() {
+			err, _ := recover().(error)
+			var decodeErr *encodingmust.DecodeError
+			if !errors.As(err, &decodeErr) {
+				t.Fatalf("expected *encodingmust.DecodeError, got %v", err)
+			}
+			if decodeErr.Line != 4 || decodeErr.Excerpt != "replicas: many" {
+				t.Errorf("expected line 4 with excerpt, got line %d, excerpt %q", decodeErr.Line, decodeErr.Excerpt)
+			}
+		}()
+		yamlmust.UnmarshalAs[manifest]([]byte("kind: Deployment\nmetadata:\n  name: web\nreplicas: many\n"))
+	})
+
+	t.Run("syntax error location", func(t *testing.T) {
+		defer func() {
+			err, _ := recover().(error)
+			var decodeErr *encodingmust.DecodeError
+			if !errors.As(err, &decodeErr) || decodeErr.Line != 3 {
+				t.Errorf("expected *encodingmust.DecodeError on line 3, got %v", err)
+			}
+		}()
+		yamlmust.UnmarshalAs[manifest]([]byte("kind: Deployment\nmetadata:\n\tname: web\n"))
+	})
+}
+
+func TestDecoder(t *testing.T) {
+	t.Run("DocumentsAs", func(t *testing.T) {
+		dec := yamlmust.NewDecoder(iomust.ReaderOf(strings.NewReader(manifests)))
+		var kinds []string
+		for m := range yamlmust.DocumentsAs[manifest](dec) {
+			kinds = append(kinds, m.Kind)
+		}
+		if !reflect.DeepEqual(kinds, []string{"Deployment", "Service"}) {
+			t.Errorf("unexpected kinds %v", kinds)
+		}
+	})
+
+	t.Run("error in a later document", func(t *testing.T) {
+		dec := yamlmust.NewDecoder(iomust.ReaderOf(strings.NewReader(manifests + "---\nkind: [\n")))
+		defer func() {
+			err, _ := recover().(error)
+			var decodeErr *encodingmust.DecodeError
+			if !errors.As(err, &decodeErr) || decodeErr.Line < 10 {
+				t.Errorf("expected *encodingmust.DecodeError in the third document, got %v", err)
+			}
+		}()
+		for range yamlmust.DocumentsAs[manifest](dec) {
+		}
+	})
+
+	t.Run("Decode at the end panics", func(t *testing.T) {
+		dec := yamlmust.NewDecoder(iomust.ReaderOf(strings.NewReader("kind: A\n")))
+		yamlmust.DecodeAs[manifest](dec)
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("Decode did not panic at the end of the input")
+			}
+		}()
+		yamlmust.DecodeAs[manifest](dec)
+	})
+
+	t.Run("KnownFields", func(t *testing.T) {
+		dec := yamlmust.NewDecoder(iomust.ReaderOf(strings.NewReader("kind: A\nunknown: 1\n")))
+		dec.KnownFields(true)
+		defer func() {
+			err, _ := recover().(error)
+			var decodeErr *encodingmust.DecodeError
+			if !errors.As(err, &decodeErr) || decodeErr.Line != 2 || decodeErr.Column != 0 || decodeErr.Excerpt != "unknown: 1" {
+				t.Errorf("expected *encodingmust.DecodeError on line 2, got %v", err)
+			}
+		}()
+		yamlmust.DecodeAs[manifest](dec)
+	})
+}
+
+func TestEncoder(t *testing.T) {
+	buf := &bytes.Buffer{}
+	enc := yamlmust.NewEncoder(iomust.WriterOf(buf))
+	enc.SetIndent(2)
+	enc.Encode(map[string]any{"kind": "A", "metadata": map[string]string{"name": "a"}})
+	enc.Encode(map[string]any{"kind": "B"})
+	enc.Close()
+	expected := "kind: A\nmetadata:\n  name: a\n---\nkind: B\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+
+	docs := yamlmust.MarshalDocuments(slices.Values([]string{"a", "b"}))
+	if string(docs) != "a\n---\nb\n" {
+		t.Errorf("unexpected documents %q", docs)
+	}
+}
@@ -0,0 +1,287 @@
+// Package hashmust provides checksums of data, files and directory trees with panicking error handling.
+package hashmust
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"hash/fnv"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/Jumpaku/go-mustd"
+	"github.com/Jumpaku/go-mustd/iomust"
+)
+
+// Algorithm is a hash algorithm.
+type Algorithm int
+
+const (
+	// SHA256 is SHA-256 (FIPS 180-4).
+	SHA256 Algorithm = iota
+	// SHA512 is SHA-512 (FIPS 180-4).
+	SHA512
+	// SHA1 is SHA-1, which is cryptographically broken and should be used only for compatibility.
+	SHA1
+	// MD5 is MD5 (RFC 1321), which is cryptographically broken and should be used only for compatibility.
+	MD5
+	// CRC32 is CRC-32 with the IEEE polynomial, which is not a cryptographic hash.
+	CRC32
+	// FNV is 64-bit FNV-1a, which is not a cryptographic hash.
+	FNV
+)
+
+func (a Algorithm) String() string {
+	switch a {
+	case SHA256:
+		return "sha256"
+	case SHA512:
+		return "sha512"
+	case SHA1:
+		return "sha1"
+	case MD5:
+		return "md5"
+	case CRC32:
+		return "crc32"
+	case FNV:
+		return "fnv"
+	default:
+		return fmt.Sprintf("Algorithm(%d)", int(a))
+	}
+}
+
+// New returns a new hash.Hash computing the checksum of a. Panics if a is unknown.
+func (a Algorithm) New() hash.Hash {
+	switch a {
+	case SHA256:
+		return sha256.New()
+	case SHA512:
+		return sha512.New()
+	case SHA1:
+		return sha1.New()
+	case MD5:
+		return md5.New()
+	case CRC32:
+		return crc32.NewIEEE()
+	case FNV:
+		return fnv.New64a()
+	default:
+		panic(fmt.Errorf("hashmust: unknown algorithm %v", a))
+	}
+}
+
+// Sum returns the checksum of data.
+func Sum(data []byte, algo Algorithm) []byte {
+	h := algo.New()
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// Reader returns the checksum of the data read from r until EOF. Panics if an error occurs.
+func Reader(r iomust.Reader, algo Algorithm) []byte {
+	h := algo.New()
+	iomust.Copy(iomust.WriterOf(h), r)
+	return h.Sum(nil)
+}
+
+// File returns the checksum of the content of the named file. Panics if an error occurs.
+func File(name string, algo Algorithm) []byte {
+	return mustd.Must1(fileSum(name, algo))
+}
+
+// fileSum returns the checksum of the content of the named file.
+func fileSum(name string, algo Algorithm) ([]byte, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	h := algo.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// FileHex returns the hex-encoded checksum of the content of the named file. Panics if an error occurs.
+func FileHex(name string, algo Algorithm) string {
+	return hex.EncodeToString(File(name, algo))
+}
+
+// Dir returns the SHA-256 tree hash of the directory dir, which depends only on the names, the types, the contents of regular files,
+// the executable bits and the targets of symbolic links of the files in dir, and not on their modification times or owners.
+// The tree hash is the hash of a line per file in lexical order of the slash-separated paths,
+// which consists of the kind of the file ("d" for a directory, "f" for a regular file, "x" for an executable file, "l" for a symbolic link),
+// the hex-encoded SHA-256 checksum of the content, or the target of a symbolic link, and the path.
+// If dir is a symbolic link, the directory it refers to is hashed. Panics if an error occurs or a file of another type is found.
+func Dir(dir string) []byte {
+	dir = mustd.Must1(filepath.EvalSymlinks(dir))
+	type fileLine struct{ path, line string }
+	var lines []fileLine
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel := mustd.Must1(filepath.Rel(dir, p))
+		if rel == "." {
+			return nil
+		}
+		info := mustd.Must1(d.Info())
+		var kind, content string
+		switch mode := info.Mode(); {
+		case mode.IsDir():
+			kind = "d"
+		case mode.IsRegular():
+			kind, content = "f", FileHex(p, SHA256)
+			if mode.Perm()&0o111 != 0 {
+				kind = "x"
+			}
+		case mode&fs.ModeSymlink != 0:
+			kind, content = "l", filepath.ToSlash(mustd.Must1(os.Readlink(p)))
+		default:
+			return fmt.Errorf("hashmust: %q: unsupported file type %v", p, mode.Type())
+		}
+		path := filepath.ToSlash(rel)
+		lines = append(lines, fileLine{path: path, line: fmt.Sprintf("%s %q %q\n", kind, content, path)})
+		return nil
+	})
+	mustd.Must0(err)
+	slices.SortFunc(lines, func(a, b fileLine) int { return strings.Compare(a.path, b.path) })
+	h := sha256.New()
+	for _, l := range lines {
+		io.WriteString(h, l.line)
+	}
+	return h.Sum(nil)
+}
+
+// DirHex returns the hex-encoded tree hash of the directory dir computed as Dir. Panics if an error occurs.
+func DirHex(dir string) string {
+	return hex.EncodeToString(Dir(dir))
+}
+
+// Mismatch describes a file listed in a checksum file which failed verification.
+type Mismatch struct {
+	// Name is the name of the file as listed in the checksum file.
+	Name string
+	// Expected is the hex-encoded checksum listed in the checksum file.
+	Expected string
+	// Actual is the hex-encoded checksum of the file, or empty if it could not be read.
+	Actual string
+	// Err is the error reading the file, or nil.
+	Err error
+}
+
+func (m Mismatch) String() string {
+	if m.Err != nil {
+		return fmt.Sprintf("%s: %v", m.Name, m.Err)
+	}
+	return fmt.Sprintf("%s: expected %s, got %s", m.Name, m.Expected, m.Actual)
+}
+
+// VerifyError describes files which failed verification against a checksum file.
+type VerifyError struct {
+	// SumsFile is the name of the checksum file.
+	SumsFile   string
+	Mismatches []Mismatch
+}
+
+func (e *VerifyError) Error() string {
+	lines := []string{fmt.Sprintf("hashmust: %s: %d file(s) failed verification", e.SumsFile, len(e.Mismatches))}
+	for _, m := range e.Mismatches {
+		lines = append(lines, m.String())
+	}
+	return strings.Join(lines, "\n\t")
+}
+
+// algorithmOf returns the algorithm producing hex-encoded checksums of the length n.
+func algorithmOf(n int) (Algorithm, bool) {
+	for _, algo := range []Algorithm{MD5, SHA1, SHA256, SHA512} {
+		if n == hex.EncodedLen(algo.New().Size()) {
+			return algo, true
+		}
+	}
+	return 0, false
+}
+
+// VerifySumsFile checks the files listed in the named checksum file in the format of sha256sum and similar commands,
+// in which each line is a hex-encoded checksum followed by two spaces, or a space and an asterisk, and the name of a file.
+// A line starting with a backslash has a name in which backslashes, newlines and carriage returns are escaped as \\, \n and \r.
+// The algorithm is MD5, SHA1, SHA256 or SHA512 detected by the length of the checksums, and relative names are resolved against the directory of the checksum file.
+// Panics with a *VerifyError listing every missing, unreadable or mismatching file, or panics if the checksum file cannot be read or parsed.
+func VerifySumsFile(name string) {
+	content := mustd.Must1(os.ReadFile(name))
+	var mismatches []Mismatch
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSuffix(scanner.Text(), "\r")
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		escaped := strings.HasPrefix(text, `\`)
+		expected, file, ok := strings.Cut(strings.TrimPrefix(text, `\`), " ")
+		algo, known := algorithmOf(len(expected))
+		if _, err := hex.DecodeString(expected); !ok || !known || err != nil || !strings.HasPrefix(file, " ") && !strings.HasPrefix(file, "*") {
+			panic(fmt.Errorf("hashmust: %s:%d: malformed line %q", name, line, text))
+		}
+		file = file[1:]
+		if escaped {
+			if file, ok = unescapeName(file); !ok {
+				panic(fmt.Errorf("hashmust: %s:%d: malformed line %q", name, line, text))
+			}
+		}
+		path := file
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(name), filepath.FromSlash(path))
+		}
+		m := Mismatch{Name: file, Expected: strings.ToLower(expected)}
+		actual, err := fileSum(path, algo)
+		if err != nil {
+			m.Err = err
+		} else {
+			m.Actual = hex.EncodeToString(actual)
+		}
+		if m.Err != nil || m.Actual != m.Expected {
+			mismatches = append(mismatches, m)
+		}
+	}
+	mustd.Must0(scanner.Err())
+	if len(mismatches) > 0 {
+		panic(&VerifyError{SumsFile: name, Mismatches: mismatches})
+	}
+}
+
+// unescapeName returns the file name escaped by sha256sum and similar commands, or false if it contains an unknown escape sequence.
+func unescapeName(s string) (string, bool) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+			continue
+		}
+		if i++; i == len(s) {
+			return "", false
+		}
+		switch s[i] {
+		case '\\':
+			b.WriteByte('\\')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		default:
+			return "", false
+		}
+	}
+	return b.String(), true
+}
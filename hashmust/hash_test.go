@@ -0,0 +1,188 @@
+package hashmust_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Jumpaku/go-mustd/hashmust"
+	"github.com/Jumpaku/go-mustd/iomust"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestSum(t *testing.T) {
+	// Checksums of "hello" computed by the sha256sum, sha512sum, sha1sum, md5sum and crc32 commands, and FNV-1a 64.
+	expected := map[hashmust.Algorithm]string{
+		hashmust.SHA256: "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824",
+		hashmust.SHA512: "9b71d224bd62f3785d96d46ad3ea3d73319bfbc2890caadae2dff72519673ca72323c3d99ba5c11d7c7acc6e14b8c5da0c4663475c2e5c3adef46f73bcdec043",
+		hashmust.SHA1:   "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d",
+		hashmust.MD5:    "5d41402abc4b2a76b9719d911017c592",
+		hashmust.CRC32:  "3610a686",
+		hashmust.FNV:    "a430d84680aabd0b",
+	}
+	name := filepath.Join(t.TempDir(), "hello.txt")
+	writeFiles(t, filepath.Dir(name), map[string]string{"hello.txt": "hello"})
+	for algo, sum := range expected {
+		t.Run(algo.String(), func(t *testing.T) {
+			if got := hex.EncodeToString(hashmust.Sum([]byte("hello"), algo)); got != sum {
+				t.Errorf("Sum: expected %s, got %s", sum, got)
+			}
+			if got := hex.EncodeToString(hashmust.Reader(iomust.ReaderOf(strings.NewReader("hello")), algo)); got != sum {
+				t.Errorf("Reader: expected %s, got %s", sum, got)
+			}
+			if got := hashmust.FileHex(name, algo); got != sum {
+				t.Errorf("FileHex: expected %s, got %s", sum, got)
+			}
+		})
+	}
+
+	t.Run("File panics on missing file", func(t *testing.T) {
+		defer func() {
+			err, _ := recover().(error)
+			if !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("expected fs.ErrNotExist, got %v", err)
+			}
+		}()
+		hashmust.File(filepath.Join(t.TempDir(), "missing"), hashmust.SHA256)
+	})
+
+	t.Run("unknown algorithm panics", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("New did not panic")
+			}
+		}()
+		hashmust.Algorithm(100).New()
+	})
+}
+
+func TestDir(t *testing.T) {
+	files := map[string]string{"a.txt": "alpha", "sub/b.txt": "beta"}
+	first, second := t.TempDir(), t.TempDir()
+	writeFiles(t, first, files)
+	writeFiles(t, second, files)
+	if hashmust.DirHex(first) != hashmust.DirHex(second) {
+		t.Fatal("tree hashes of identical trees differ")
+	}
+
+	t.Run("lexical order of the paths", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFiles(t, dir, map[string]string{"a/x": "x", "a-b": "ab"})
+		sum := func(s string) string { return hex.EncodeToString(hashmust.Sum([]byte(s), hashmust.SHA256)) }
+		expected := sha256.Sum256([]byte(`d "" "a"` + "\n" + `f "` + sum("ab") + `" "a-b"` + "\n" + `f "` + sum("x") + `" "a/x"` + "\n"))
+		if got := hashmust.DirHex(dir); got != hex.EncodeToString(expected[:]) {
+			t.Errorf("unexpected tree hash %s", got)
+		}
+	})
+
+	t.Run("symbolic link to the directory", func(t *testing.T) {
+		link := filepath.Join(t.TempDir(), "link")
+		if err := os.Symlink(first, link); err != nil {
+			t.Fatal(err)
+		}
+		if hashmust.DirHex(link) != hashmust.DirHex(first) {
+			t.Error("tree hash of the linked directory differs")
+		}
+	})
+
+	changes := map[string]func(dir string) error{
+		"content": func(dir string) error {
+			return os.WriteFile(filepath.Join(dir, "a.txt"), []byte("ALPHA"), 0o644)
+		},
+		"name": func(dir string) error {
+			return os.Rename(filepath.Join(dir, "sub/b.txt"), filepath.Join(dir, "sub/c.txt"))
+		},
+		"executable bit": func(dir string) error {
+			return os.Chmod(filepath.Join(dir, "a.txt"), 0o755)
+		},
+		"empty directory": func(dir string) error {
+			return os.Mkdir(filepath.Join(dir, "empty"), 0o755)
+		},
+		"symbolic link": func(dir string) error {
+			return os.Symlink("a.txt", filepath.Join(dir, "link"))
+		},
+	}
+	for name, change := range changes {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, files)
+			if err := change(dir); err != nil {
+				t.Fatal(err)
+			}
+			if hashmust.DirHex(dir) == hashmust.DirHex(first) {
+				t.Error("tree hash did not change")
+			}
+		})
+	}
+}
+
+func TestVerifySumsFile(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"hello.txt": "hello", "sub/world.txt": "world"})
+	helloSum := hashmust.FileHex(filepath.Join(dir, "hello.txt"), hashmust.SHA256)
+	worldSum := hashmust.FileHex(filepath.Join(dir, "sub/world.txt"), hashmust.SHA256)
+
+	t.Run("all files match", func(t *testing.T) {
+		writeFiles(t, dir, map[string]string{"SHA256SUMS": helloSum + "  hello.txt\n" + strings.ToUpper(worldSum) + " *sub/world.txt\n"})
+		hashmust.VerifySumsFile(filepath.Join(dir, "SHA256SUMS"))
+	})
+
+	t.Run("md5", func(t *testing.T) {
+		writeFiles(t, dir, map[string]string{"MD5SUMS": "5d41402abc4b2a76b9719d911017c592  hello.txt\r\n"})
+		hashmust.VerifySumsFile(filepath.Join(dir, "MD5SUMS"))
+	})
+
+	t.Run("escaped names", func(t *testing.T) {
+		writeFiles(t, dir, map[string]string{`back\slash`: "hello", "new\nline": "hello"})
+		writeFiles(t, dir, map[string]string{"SHA256SUMS": `\` + helloSum + `  back\\slash` + "\n" + `\` + helloSum + `  new\nline` + "\n"})
+		hashmust.VerifySumsFile(filepath.Join(dir, "SHA256SUMS"))
+	})
+
+	t.Run("mismatches", func(t *testing.T) {
+		writeFiles(t, dir, map[string]string{"SHA256SUMS": worldSum + "  hello.txt\n" + worldSum + "  sub/world.txt\n" + worldSum + "  missing.txt\n"})
+		defer func() {
+			err, _ := recover().(*hashmust.VerifyError)
+			if err == nil || len(err.Mismatches) != 2 {
+				t.Fatalf("expected 2 mismatches, got %v", err)
+			}
+			if m := err.Mismatches[0]; m.Name != "hello.txt" || m.Expected != worldSum || m.Actual != helloSum {
+				t.Errorf("unexpected mismatch %+v", m)
+			}
+			if m := err.Mismatches[1]; m.Name != "missing.txt" || !errors.Is(m.Err, fs.ErrNotExist) {
+				t.Errorf("unexpected mismatch %+v", m)
+			}
+			if !strings.Contains(err.Error(), "2 file(s) failed verification\n\thello.txt: expected ") {
+				t.Errorf("unexpected message %q", err.Error())
+			}
+		}()
+		hashmust.VerifySumsFile(filepath.Join(dir, "SHA256SUMS"))
+	})
+
+	t.Run("malformed line", func(t *testing.T) {
+		writeFiles(t, dir, map[string]string{"BADSUMS": "not a checksum line\n"})
+		defer func() {
+			err, _ := recover().(error)
+			if err == nil || !strings.Contains(err.Error(), "BADSUMS:1: malformed line") {
+				t.Errorf("unexpected error %v", err)
+			}
+		}()
+		hashmust.VerifySumsFile(filepath.Join(dir, "BADSUMS"))
+	})
+}
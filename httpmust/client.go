@@ -0,0 +1,203 @@
+// Package httpmust provides an HTTP client and server helpers based on the net/http package with panicking error handling.
+package httpmust
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Jumpaku/go-mustd"
+	"github.com/Jumpaku/go-mustd/encodingmust/jsonmust"
+)
+
+// maxErrorBody is the maximum number of bytes of a response body included in a ResponseError.
+const maxErrorBody = 1024
+
+// ResponseError is reported when a response has a status code which is not allowed.
+type ResponseError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	// Status is the status line such as "404 Not Found".
+	Status string
+	// Body is the response body truncated to 1024 bytes.
+	Body string
+}
+
+func (e *ResponseError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("httpmust: %s %s: %s", e.Method, e.URL, e.Status)
+	}
+	return fmt.Sprintf("httpmust: %s %s: %s: %s", e.Method, e.URL, e.Status, e.Body)
+}
+
+// Response is an HTTP response whose body is read entirely and closed.
+type Response struct {
+	// Response is the underlying response. Its Body is already read and closed.
+	*http.Response
+	body []byte
+}
+
+// Bytes returns the response body.
+func (r *Response) Bytes() []byte {
+	return r.body
+}
+
+// Text returns the response body as a string.
+func (r *Response) Text() string {
+	return string(r.body)
+}
+
+// JSON parses the JSON-encoded response body and stores the result in v. Panics if an error occurs as jsonmust.Unmarshal.
+func (r *Response) JSON(v any) {
+	jsonmust.Unmarshal(r.body, v)
+}
+
+// JSONAs parses the JSON-encoded body of res and returns the result as a value of type T. Panics if an error occurs as jsonmust.UnmarshalAs.
+func JSONAs[T any](res *Response) T {
+	return jsonmust.UnmarshalAs[T](res.body)
+}
+
+// Request is a builder of an HTTP request. Its methods modify and return the receiver so that they can be chained.
+type Request struct {
+	method      string
+	url         string
+	header      http.Header
+	query       url.Values
+	body        []byte
+	hasBody     bool
+	timeout     time.Duration
+	ctx         context.Context
+	client      *http.Client
+	allowStatus []int
+}
+
+// NewRequest returns a new Request with the method and the URL sent by http.DefaultClient.
+func NewRequest(method, rawURL string) *Request {
+	return &Request{method: method, url: rawURL, header: http.Header{}, query: url.Values{}, ctx: context.Background(), client: http.DefaultClient}
+}
+
+// Header adds the header field.
+func (r *Request) Header(key, value string) *Request {
+	r.header.Add(key, value)
+	return r
+}
+
+// Query adds the query parameter to the URL.
+func (r *Request) Query(key, value string) *Request {
+	r.query.Add(key, value)
+	return r
+}
+
+// BasicAuth sets the Authorization header field to use HTTP Basic Authentication with username and password.
+func (r *Request) BasicAuth(username, password string) *Request {
+	r.header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(username+":"+password)))
+	return r
+}
+
+// BearerToken sets the Authorization header field to use the bearer token.
+func (r *Request) BearerToken(token string) *Request {
+	r.header.Set("Authorization", "Bearer "+token)
+	return r
+}
+
+// Body sets the request body and the Content-Type header field.
+func (r *Request) Body(contentType string, body []byte) *Request {
+	r.header.Set("Content-Type", contentType)
+	r.body, r.hasBody = body, true
+	return r
+}
+
+// JSON sets the JSON encoding of v as the request body. Panics if an error occurs.
+func (r *Request) JSON(v any) *Request {
+	return r.Body("application/json", jsonmust.Marshal(v))
+}
+
+// Form sets the URL-encoded values as the request body.
+func (r *Request) Form(values url.Values) *Request {
+	return r.Body("application/x-www-form-urlencoded", []byte(values.Encode()))
+}
+
+// Timeout sets the time limit for sending the request and reading the response body. Zero means no limit.
+func (r *Request) Timeout(d time.Duration) *Request {
+	r.timeout = d
+	return r
+}
+
+// Context sets the context of the request.
+func (r *Request) Context(ctx context.Context) *Request {
+	r.ctx = ctx
+	return r
+}
+
+// Client sets the client sending the request.
+func (r *Request) Client(c *http.Client) *Request {
+	r.client = c
+	return r
+}
+
+// AllowStatus allows the status codes in addition to 2xx.
+func (r *Request) AllowStatus(codes ...int) *Request {
+	r.allowStatus = append(r.allowStatus, codes...)
+	return r
+}
+
+// Do sends the request and returns the response whose body is read and closed.
+// Panics with a *ResponseError if the status code is not 2xx nor allowed by AllowStatus, or panics if an error occurs.
+func (r *Request) Do() *Response {
+	ctx := r.ctx
+	if r.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.timeout)
+		defer cancel()
+	}
+	u := mustd.Must1(url.Parse(r.url))
+	if len(r.query) > 0 {
+		query := u.Query()
+		for key, values := range r.query {
+			query[key] = append(query[key], values...)
+		}
+		u.RawQuery = query.Encode()
+	}
+	var body io.Reader
+	if r.hasBody {
+		body = bytes.NewReader(r.body)
+	}
+	req := mustd.Must1(http.NewRequestWithContext(ctx, r.method, u.String(), body))
+	for key, values := range r.header {
+		req.Header[key] = slices.Clone(values)
+	}
+	res := mustd.Must1(r.client.Do(req))
+	defer res.Body.Close()
+	data := mustd.Must1(io.ReadAll(res.Body))
+	if res.StatusCode/100 != 2 && !slices.Contains(r.allowStatus, res.StatusCode) {
+		text := string(data)
+		if len(text) > maxErrorBody {
+			text = strings.ToValidUTF8(text[:maxErrorBody], "") + "..."
+		}
+		panic(&ResponseError{Method: req.Method, URL: u.Redacted(), StatusCode: res.StatusCode, Status: res.Status, Body: text})
+	}
+	return &Response{Response: res, body: data}
+}
+
+// Get sends a GET request to url with http.DefaultClient. Panics as Request.Do.
+func Get(url string) *Response {
+	return NewRequest(http.MethodGet, url).Do()
+}
+
+// Post sends a POST request with the body of the content type to url with http.DefaultClient. Panics as Request.Do.
+func Post(url, contentType string, body []byte) *Response {
+	return NewRequest(http.MethodPost, url).Body(contentType, body).Do()
+}
+
+// PostJSON sends a POST request with the JSON encoding of v to url with http.DefaultClient. Panics as Request.Do.
+func PostJSON(url string, v any) *Response {
+	return NewRequest(http.MethodPost, url).JSON(v).Do()
+}
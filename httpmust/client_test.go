@@ -0,0 +1,196 @@
+package httpmust_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Jumpaku/go-mustd/encodingmust"
+	"github.com/Jumpaku/go-mustd/httpmust"
+)
+
+type item struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// newServer returns a server echoing requests as JSON, except for a not found error at /status, a slow response at /slow and items at /items.
+func newServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/status":
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, strings.Repeat("not found ", 200))
+		case "/slow":
+			<-r.Context().Done()
+		case "/items":
+			w.Header().Set("Content-Type", "application/json")
+			io.WriteString(w, `[{"name":"apple","count":3},{"name":"pear","count":1}]`)
+		default:
+			body, _ := io.ReadAll(r.Body)
+			json.NewEncoder(w).Encode(map[string]any{
+				"method":        r.Method,
+				"query":         r.URL.Query(),
+				"authorization": r.Header.Get("Authorization"),
+				"contentType":   r.Header.Get("Content-Type"),
+				"custom":        r.Header.Values("X-Custom"),
+				"body":          string(body),
+			})
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+type echo struct {
+	Method        string              `json:"method"`
+	Query         map[string][]string `json:"query"`
+	Authorization string              `json:"authorization"`
+	ContentType   string              `json:"contentType"`
+	Custom        []string            `json:"custom"`
+	Body          string              `json:"body"`
+}
+
+func TestGet(t *testing.T) {
+	server := newServer(t)
+
+	t.Run("JSON", func(t *testing.T) {
+		res := httpmust.Get(server.URL + "/items")
+		items := httpmust.JSONAs[[]item](res)
+		if len(items) != 2 || items[0] != (item{Name: "apple", Count: 3}) {
+			t.Errorf("unexpected items %v", items)
+		}
+		var raw []map[string]any
+		res.JSON(&raw)
+		if res.StatusCode != http.StatusOK || res.Header.Get("Content-Type") != "application/json" || len(raw) != 2 {
+			t.Errorf("unexpected response %d %v %v", res.StatusCode, res.Header, raw)
+		}
+		if !strings.HasPrefix(res.Text(), `[{"name":"apple"`) || string(res.Bytes()) != res.Text() {
+			t.Errorf("unexpected body %q", res.Text())
+		}
+	})
+
+	t.Run("non-2xx status panics", func(t *testing.T) {
+		defer func() {
+			err, _ := recover().(*httpmust.ResponseError)
+			if err == nil {
+				t.Fatal("expected *httpmust.ResponseError")
+			}
+			if err.StatusCode != http.StatusNotFound || err.Method != http.MethodGet || err.URL != server.URL+"/status" {
+				t.Errorf("unexpected error %+v", err)
+			}
+			if len(err.Body) != 1024+len("...") || !strings.HasPrefix(err.Error(), "httpmust: GET "+server.URL+"/status: 404 Not Found: not found") {
+				t.Errorf("unexpected message %q", err.Error())
+			}
+		}()
+		httpmust.Get(server.URL + "/status")
+	})
+
+	t.Run("invalid JSON panics with location", func(t *testing.T) {
+		res := httpmust.Get(server.URL + "/items")
+		defer func() {
+			var decodeErr *encodingmust.DecodeError
+			if err, _ := recover().(error); !errors.As(err, &decodeErr) {
+				t.Errorf("expected *encodingmust.DecodeError, got %v", err)
+			}
+		}()
+		httpmust.JSONAs[map[string]int](res)
+	})
+
+	t.Run("connection error panics", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("Get did not panic")
+			}
+		}()
+		httpmust.Get("http://127.0.0.1:0/")
+	})
+}
+
+func TestPost(t *testing.T) {
+	server := newServer(t)
+
+	t.Run("PostJSON", func(t *testing.T) {
+		got := httpmust.JSONAs[echo](httpmust.PostJSON(server.URL, item{Name: "apple", Count: 3}))
+		if got.Method != http.MethodPost || got.ContentType != "application/json" || got.Body != `{"name":"apple","count":3}` {
+			t.Errorf("unexpected request %+v", got)
+		}
+	})
+
+	t.Run("Post", func(t *testing.T) {
+		got := httpmust.JSONAs[echo](httpmust.Post(server.URL, "text/plain", []byte("hello")))
+		if got.ContentType != "text/plain" || got.Body != "hello" {
+			t.Errorf("unexpected request %+v", got)
+		}
+	})
+}
+
+func TestRequest(t *testing.T) {
+	server := newServer(t)
+
+	t.Run("builder", func(t *testing.T) {
+		res := httpmust.NewRequest(http.MethodPut, server.URL+"/?a=1").
+			Query("a", "2").
+			Query("b", "x y").
+			Header("X-Custom", "one").
+			Header("X-Custom", "two").
+			BasicAuth("user", "pass").
+			Form(url.Values{"k": {"v"}}).
+			Timeout(time.Second).
+			Do()
+		got := httpmust.JSONAs[echo](res)
+		if got.Method != http.MethodPut || strings.Join(got.Query["a"], ",") != "1,2" || got.Query["b"][0] != "x y" {
+			t.Errorf("unexpected method or query %+v", got)
+		}
+		if strings.Join(got.Custom, ",") != "one,two" || got.Authorization != "Basic dXNlcjpwYXNz" {
+			t.Errorf("unexpected headers %+v", got)
+		}
+		if got.ContentType != "application/x-www-form-urlencoded" || got.Body != "k=v" {
+			t.Errorf("unexpected body %+v", got)
+		}
+	})
+
+	t.Run("BearerToken", func(t *testing.T) {
+		got := httpmust.JSONAs[echo](httpmust.NewRequest(http.MethodGet, server.URL).BearerToken("token").Do())
+		if got.Authorization != "Bearer token" {
+			t.Errorf("unexpected authorization %q", got.Authorization)
+		}
+	})
+
+	t.Run("AllowStatus", func(t *testing.T) {
+		res := httpmust.NewRequest(http.MethodGet, server.URL+"/status").AllowStatus(http.StatusNotFound).Do()
+		if res.StatusCode != http.StatusNotFound {
+			t.Errorf("unexpected status %d", res.StatusCode)
+		}
+	})
+
+	t.Run("Timeout panics", func(t *testing.T) {
+		defer func() {
+			err, _ := recover().(error)
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("expected context.DeadlineExceeded, got %v", err)
+			}
+		}()
+		httpmust.NewRequest(http.MethodGet, server.URL+"/slow").Timeout(10 * time.Millisecond).Do()
+	})
+
+	t.Run("Client and Context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		defer func() {
+			err, _ := recover().(error)
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("expected context.Canceled, got %v", err)
+			}
+		}()
+		httpmust.NewRequest(http.MethodGet, server.URL).Client(server.Client()).Context(ctx).Do()
+	})
+}
@@ -0,0 +1,233 @@
+package httpmust
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/Jumpaku/go-mustd"
+	"github.com/Jumpaku/go-mustd/encodingmust/jsonmust"
+	"github.com/Jumpaku/go-mustd/iomust"
+)
+
+// ShutdownTimeout is the time limit for active connections to finish when a server is shut down gracefully.
+const ShutdownTimeout = 10 * time.Second
+
+// Server is an HTTP server running in the background.
+type Server struct {
+	server   *http.Server
+	listener net.Listener
+	served   chan error
+	serveErr error
+	waitOnce sync.Once
+	stopped  chan struct{}
+	stopOnce sync.Once
+}
+
+// Start listens on the TCP network address addr and serves handler in the background until ctx is done,
+// when the server is shut down gracefully within ShutdownTimeout. A port 0 in addr selects an available port. Panics if listening fails.
+func Start(ctx context.Context, addr string, handler http.Handler) *Server {
+	s := &Server{
+		server:   &http.Server{Handler: handler},
+		listener: mustd.Must1(net.Listen("tcp", addr)),
+		served:   make(chan error, 1),
+		stopped:  make(chan struct{}),
+	}
+	go func() {
+		s.served <- s.server.Serve(s.listener)
+	}()
+	context.AfterFunc(ctx, func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+		defer cancel()
+		if err := s.shutdown(shutdownCtx); err != nil {
+			_ = s.server.Close()
+		}
+	})
+	return s
+}
+
+// Serve listens on the TCP network address addr and serves handler until ctx is done,
+// when the server is shut down gracefully within ShutdownTimeout. Panics if listening or serving fails.
+func Serve(ctx context.Context, addr string, handler http.Handler) {
+	Start(ctx, addr, handler).Wait()
+}
+
+// Addr returns the address on which the server listens.
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// URL returns the base URL of the server such as "http://127.0.0.1:8080".
+func (s *Server) URL() string {
+	return "http://" + s.Addr()
+}
+
+// Wait blocks until the server is shut down. Panics if serving fails.
+func (s *Server) Wait() {
+	s.waitOnce.Do(func() {
+		s.serveErr = <-s.served
+	})
+	if !errors.Is(s.serveErr, http.ErrServerClosed) {
+		panic(s.serveErr)
+	}
+	<-s.stopped
+}
+
+// Shutdown shuts down the server gracefully, waiting for the active connections to finish until ctx is done. Panics if an error occurs.
+func (s *Server) Shutdown(ctx context.Context) {
+	mustd.Must0(s.shutdown(ctx))
+}
+
+// shutdown shuts down the server gracefully and unblocks Wait.
+func (s *Server) shutdown(ctx context.Context) error {
+	err := s.server.Shutdown(ctx)
+	s.stopOnce.Do(func() {
+		close(s.stopped)
+	})
+	return err
+}
+
+// FileServer returns a handler serving the files in the directory dir, which cannot be escaped by ".." or symbolic links.
+// Panics if dir cannot be opened.
+func FileServer(dir string) http.Handler {
+	root := mustd.Must1(os.OpenRoot(dir))
+	return http.FileServerFS(root.FS())
+}
+
+// StatusError is a panic value of a handler wrapped by HandlerFunc responding with the status code.
+type StatusError struct {
+	StatusCode int
+	Err        error
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("httpmust: %d %s: %v", e.StatusCode, http.StatusText(e.StatusCode), e.Err)
+}
+
+func (e *StatusError) Unwrap() error {
+	return e.Err
+}
+
+// Fail panics with a *StatusError of the status code and the formatted message, which is recovered by HandlerFunc into a response.
+func Fail(code int, format string, args ...any) {
+	panic(&StatusError{StatusCode: code, Err: fmt.Errorf(format, args...)})
+}
+
+// statusOf returns the status code of a response to the recovered panic value.
+func statusOf(recovered any) (int, error) {
+	err, ok := recovered.(error)
+	if !ok {
+		return http.StatusInternalServerError, fmt.Errorf("%v", recovered)
+	}
+	var statusErr *StatusError
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &statusErr):
+		return statusErr.StatusCode, err
+	case errors.As(err, &maxBytesErr):
+		return http.StatusRequestEntityTooLarge, err
+	default:
+		return http.StatusInternalServerError, err
+	}
+}
+
+// HandlerFunc returns a handler calling f, which recovers a panic in f into an error response instead of crashing the server.
+// A *StatusError panicked by Fail or ReadJSONAs responds with its status code, an *http.MaxBytesError responds with 413 Request Entity Too Large,
+// and the other panics respond with 500 Internal Server Error.
+// The message of the error is written in the response body for client errors, and the other panics are logged with the stack trace.
+// A panic with http.ErrAbortHandler, or any panic after the response has been started, aborts the response by panicking with http.ErrAbortHandler.
+func HandlerFunc(f func(w http.ResponseWriter, r *http.Request)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := &responseWriter{ResponseWriter: w}
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+			code, err := statusOf(recovered)
+			if code >= 500 {
+				log.Printf("httpmust: panic serving %s %s: %v\n%s", r.Method, r.URL, err, debug.Stack())
+			}
+			if rw.wroteHeader {
+				panic(http.ErrAbortHandler)
+			}
+			message := http.StatusText(code)
+			if code < 500 {
+				message += ": " + err.Error()
+			}
+			http.Error(w, message, code)
+		}()
+		f(rw, r)
+	})
+}
+
+// responseWriter records whether the response has been started, passing Flush and Hijack through to the underlying http.ResponseWriter.
+type responseWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *responseWriter) Flush() {
+	if err := http.NewResponseController(w.ResponseWriter).Flush(); err == nil {
+		w.wroteHeader = true
+	}
+}
+
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err == nil {
+		w.wroteHeader = true
+	}
+	return conn, rw, err
+}
+
+// Unwrap returns the underlying http.ResponseWriter for http.ResponseController.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// ReadJSONAs parses the JSON-encoded body of r and returns the result as a value of type T.
+// Panics with a *StatusError of 400 Bad Request wrapping the *encodingmust.DecodeError if the body is invalid, which HandlerFunc recovers into the response.
+// Panics if reading the body fails.
+func ReadJSONAs[T any](r *http.Request, opts ...jsonmust.DecodeOption) T {
+	data := iomust.ReadAll(iomust.ReaderOf(r.Body))
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err, ok := recovered.(error)
+			if !ok {
+				panic(recovered)
+			}
+			panic(&StatusError{StatusCode: http.StatusBadRequest, Err: err})
+		}
+	}()
+	return jsonmust.UnmarshalAs[T](data, opts...)
+}
+
+// WriteJSON writes the JSON encoding of v as the response with the status code. Panics if an error occurs.
+func WriteJSON(w http.ResponseWriter, code int, v any) {
+	data := jsonmust.Marshal(v)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	mustd.Must1(w.Write(append(data, '\n')))
+}
@@ -0,0 +1,302 @@
+package httpmust_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Jumpaku/go-mustd/encodingmust/jsonmust"
+	"github.com/Jumpaku/go-mustd/httpmust"
+	"github.com/Jumpaku/go-mustd/strconvmust"
+)
+
+func TestStart(t *testing.T) {
+	t.Run("serves until context is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		s := httpmust.Start(ctx, "127.0.0.1:0", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, "hello "+r.URL.Path)
+		}))
+		if !strings.HasPrefix(s.URL(), "http://127.0.0.1:") {
+			t.Errorf("unexpected URL %q", s.URL())
+		}
+		if got := httpmust.Get(s.URL() + "/world").Text(); got != "hello /world" {
+			t.Errorf("got %q", got)
+		}
+		cancel()
+		s.Wait()
+		defer func() {
+			if recover() == nil {
+				t.Error("Get after shutdown did not panic")
+			}
+		}()
+		httpmust.Get(s.URL())
+	})
+
+	t.Run("shutdown waits for active requests", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		started, release := make(chan struct{}), make(chan struct{})
+		s := httpmust.Start(ctx, "127.0.0.1:0", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			<-release
+			io.WriteString(w, "done")
+		}))
+		result := make(chan string)
+		go func() {
+			result <- httpmust.Get(s.URL()).Text()
+		}()
+		<-started
+		cancel()
+		waited := make(chan struct{})
+		go func() {
+			s.Wait()
+			close(waited)
+		}()
+		select {
+		case <-waited:
+			t.Fatal("Wait returned before the active request finished")
+		case <-time.After(50 * time.Millisecond):
+		}
+		close(release)
+		if got := <-result; got != "done" {
+			t.Errorf("got %q", got)
+		}
+		<-waited
+	})
+
+	t.Run("Shutdown", func(t *testing.T) {
+		s := httpmust.Start(context.Background(), "127.0.0.1:0", http.NotFoundHandler())
+		s.Shutdown(context.Background())
+		s.Wait()
+	})
+
+	t.Run("address in use panics", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		s := httpmust.Start(ctx, "127.0.0.1:0", http.NotFoundHandler())
+		defer func() {
+			if recover() == nil {
+				t.Error("Start did not panic")
+			}
+		}()
+		httpmust.Start(ctx, s.Addr(), http.NotFoundHandler())
+	})
+}
+
+func TestServe(t *testing.T) {
+	t.Run("returns when context is done", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		httpmust.Serve(ctx, "127.0.0.1:0", http.NotFoundHandler())
+	})
+
+	t.Run("invalid address panics", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("Serve did not panic")
+			}
+		}()
+		httpmust.Serve(context.Background(), "127.0.0.1:-1", http.NotFoundHandler())
+	})
+}
+
+func TestFileServer(t *testing.T) {
+	outside := t.TempDir()
+	os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0o644)
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "hello.txt"), []byte("hello"), 0o644)
+	os.Symlink(filepath.Join(outside, "secret.txt"), filepath.Join(dir, "escape.txt"))
+	server := httptest.NewServer(httpmust.FileServer(dir))
+	defer server.Close()
+
+	t.Run("serves files", func(t *testing.T) {
+		if got := httpmust.Get(server.URL + "/hello.txt").Text(); got != "hello" {
+			t.Errorf("got %q", got)
+		}
+	})
+
+	t.Run("symbolic link escaping the directory is not followed", func(t *testing.T) {
+		res := httpmust.NewRequest(http.MethodGet, server.URL+"/escape.txt").AllowStatus(http.StatusNotFound, http.StatusForbidden, http.StatusInternalServerError).Do()
+		if res.StatusCode == http.StatusOK || strings.Contains(res.Text(), "secret") {
+			t.Errorf("unexpected response %s %q", res.Status, res.Text())
+		}
+	})
+
+	t.Run("missing directory panics", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("FileServer did not panic")
+			}
+		}()
+		httpmust.FileServer(filepath.Join(dir, "missing"))
+	})
+}
+
+func TestHandlerFunc(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	mux := http.NewServeMux()
+	mux.Handle("/json", httpmust.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		v := httpmust.ReadJSONAs[item](r)
+		httpmust.WriteJSON(w, http.StatusCreated, v)
+	}))
+	mux.Handle("/number", httpmust.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n, err := strconv.Atoi(r.URL.Query().Get("n"))
+		if err != nil {
+			httpmust.Fail(http.StatusBadRequest, "%w", err)
+		}
+		io.WriteString(w, strconv.Itoa(n*2))
+	}))
+	mux.Handle("/config", httpmust.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		strconvmust.Atoi("not configured")
+	}))
+	mux.Handle("/upstream", httpmust.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		jsonmust.UnmarshalAs[item]([]byte(`{"name":`))
+	}))
+	mux.Handle("/fail", httpmust.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		httpmust.Fail(http.StatusForbidden, "user %q is not allowed", "alice")
+	}))
+	mux.Handle("/limit", httpmust.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, 4)
+		httpmust.ReadJSONAs[item](r)
+	}))
+	mux.Handle("/crash", httpmust.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(errors.New("internal detail"))
+	}))
+	mux.Handle("/started", httpmust.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "partial")
+		panic("late failure")
+	}))
+	mux.Handle("/abort", httpmust.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+	mux.Handle("/flush", httpmust.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "flushed")
+		w.(http.Flusher).Flush()
+	}))
+	mux.Handle("/hijack", httpmust.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			panic(err)
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 8\r\nConnection: close\r\n\r\nhijacked")
+		rw.Flush()
+	}))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	request := func(method, path, body string) *httpmust.Response {
+		return httpmust.NewRequest(method, server.URL+path).Body("application/json", []byte(body)).AllowStatus(
+			http.StatusBadRequest, http.StatusForbidden, http.StatusRequestEntityTooLarge, http.StatusInternalServerError,
+		).Do()
+	}
+
+	t.Run("successful response", func(t *testing.T) {
+		res := request(http.MethodPost, "/json", `{"name":"apple","count":3}`)
+		if res.StatusCode != http.StatusCreated || res.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected response %s %q", res.Status, res.Header.Get("Content-Type"))
+		}
+		if got := httpmust.JSONAs[item](res); got != (item{Name: "apple", Count: 3}) {
+			t.Errorf("got %+v", got)
+		}
+	})
+
+	t.Run("decode failure responds 400", func(t *testing.T) {
+		for _, body := range []string{`{"name":`, ``} {
+			res := request(http.MethodPost, "/json", body)
+			if res.StatusCode != http.StatusBadRequest || !strings.HasPrefix(res.Text(), "Bad Request: ") {
+				t.Errorf("body %q: unexpected response %s %q", body, res.Status, res.Text())
+			}
+		}
+	})
+
+	t.Run("parse failure responds 400", func(t *testing.T) {
+		if got := request(http.MethodGet, "/number?n=21", "").Text(); got != "42" {
+			t.Errorf("got %q", got)
+		}
+		res := request(http.MethodGet, "/number?n=x", "")
+		if res.StatusCode != http.StatusBadRequest || !strings.Contains(res.Text(), `"x"`) {
+			t.Errorf("unexpected response %s %q", res.Status, res.Text())
+		}
+	})
+
+	t.Run("parse and decode failures outside request parsing respond 500", func(t *testing.T) {
+		for _, path := range []string{"/config", "/upstream"} {
+			res := request(http.MethodGet, path, "")
+			if res.StatusCode != http.StatusInternalServerError || res.Text() != "Internal Server Error\n" {
+				t.Errorf("%s: unexpected response %s %q", path, res.Status, res.Text())
+			}
+		}
+	})
+
+	t.Run("Fail responds with status code", func(t *testing.T) {
+		res := request(http.MethodGet, "/fail", "")
+		if res.StatusCode != http.StatusForbidden || !strings.Contains(res.Text(), `user "alice" is not allowed`) {
+			t.Errorf("unexpected response %s %q", res.Status, res.Text())
+		}
+	})
+
+	t.Run("too large body responds 413", func(t *testing.T) {
+		res := request(http.MethodPost, "/limit", `{"name":"apple"}`)
+		if res.StatusCode != http.StatusRequestEntityTooLarge {
+			t.Errorf("unexpected response %s %q", res.Status, res.Text())
+		}
+	})
+
+	t.Run("other panic responds 500 and is logged", func(t *testing.T) {
+		logs.Reset()
+		res := request(http.MethodGet, "/crash", "")
+		if res.StatusCode != http.StatusInternalServerError || strings.Contains(res.Text(), "internal detail") {
+			t.Errorf("unexpected response %s %q", res.Status, res.Text())
+		}
+		if !strings.Contains(logs.String(), "httpmust: panic serving GET /crash: internal detail") {
+			t.Errorf("unexpected log %q", logs.String())
+		}
+	})
+
+	t.Run("panic after response started aborts the response", func(t *testing.T) {
+		logs.Reset()
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error("request did not panic")
+				}
+			}()
+			request(http.MethodGet, "/started", "")
+		}()
+		if !strings.Contains(logs.String(), "httpmust: panic serving GET /started: late failure") {
+			t.Errorf("unexpected log %q", logs.String())
+		}
+	})
+
+	t.Run("Flush and Hijack", func(t *testing.T) {
+		if got := request(http.MethodGet, "/flush", "").Text(); got != "flushed" {
+			t.Errorf("got %q", got)
+		}
+		if got := request(http.MethodGet, "/hijack", "").Text(); got != "hijacked" {
+			t.Errorf("got %q", got)
+		}
+	})
+
+	t.Run("ErrAbortHandler aborts the response", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("request did not panic")
+			}
+		}()
+		request(http.MethodGet, "/abort", "")
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"os"
 	"os/exec"
+	"sync"
 	"syscall"
 	"time"
 
@@ -19,11 +20,20 @@ func Command(name string, arg ...string) *Cmd {
 	return &Cmd{cmd: exec.Command(name, arg...)}
 }
 func CommandContext(ctx context.Context, name string, arg ...string) *Cmd {
-	return &Cmd{cmd: exec.CommandContext(ctx, name, arg...)}
+	c := &Cmd{cmd: exec.CommandContext(ctx, name, arg...)}
+	c.cmd.Cancel = c.cancel
+	return c
 }
 
 type Cmd struct {
-	cmd *exec.Cmd
+	cmd          *exec.Cmd
+	cancelSignal os.Signal
+
+	mu        sync.Mutex
+	killTimer *time.Timer
+	killAt    time.Time
+	killed    bool
+	waited    bool
 }
 
 func (c *Cmd) SetPath(path string) {
@@ -147,15 +157,18 @@ func (c *Cmd) WaitDelay() time.Duration {
 }
 
 func (c *Cmd) CombinedOutput() []byte {
+	defer c.finishWait()
 	return mustd.Must1(c.cmd.CombinedOutput())
 }
 func (c *Cmd) Environ() []string {
 	return c.cmd.Environ()
 }
 func (c *Cmd) Output() []byte {
+	defer c.finishWait()
 	return mustd.Must1(c.cmd.Output())
 }
 func (c *Cmd) Run() {
+	defer c.finishWait()
 	mustd.Must0(c.cmd.Run())
 }
 func (c *Cmd) Start() {
@@ -174,5 +187,6 @@ func (c *Cmd) String() string {
 	return c.cmd.String()
 }
 func (c *Cmd) Wait() {
+	defer c.finishWait()
 	mustd.Must0(c.cmd.Wait())
 }
@@ -0,0 +1,573 @@
+package execmust_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/Jumpaku/go-mustd/iomust"
+	"github.com/Jumpaku/go-mustd/osmust/execmust"
+)
+
+// groupExited reports whether no process remains in the process group of cmd.
+func groupExited(cmd *execmust.Cmd) (exited bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			exited = true
+		}
+	}()
+	cmd.Signal(syscall.Signal(0))
+	return false
+}
+
+func waitGroupExited(t *testing.T, cmd *execmust.Cmd) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !groupExited(cmd) {
+		if time.Now().After(deadline) {
+			t.Fatal("processes in the group remain")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestProcessGroup(t *testing.T) {
+	t.Run("SetNewProcessGroup", func(t *testing.T) {
+		cmd := execmust.Command("true")
+		if cmd.NewProcessGroup() {
+			t.Error("NewProcessGroup should be false by default")
+		}
+		cmd.SetNewProcessGroup(true)
+		if !cmd.NewProcessGroup() {
+			t.Error("NewProcessGroup should be true")
+		}
+		cmd.SetNewProcessGroup(false)
+		if cmd.NewProcessGroup() {
+			t.Error("NewProcessGroup should be false")
+		}
+	})
+
+	t.Run("KillTree kills all processes in the group", func(t *testing.T) {
+		cmd := execmust.Command("sh", "-c", "sleep 100 & sleep 100")
+		cmd.SetNewProcessGroup(true)
+		cmd.Start()
+
+		cmd.KillTree()
+		func() {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Error("Wait did not panic for killed process")
+				}
+			}()
+			cmd.Wait()
+		}()
+		waitGroupExited(t, cmd)
+	})
+
+	t.Run("Signal sends signal to the group", func(t *testing.T) {
+		cmd := execmust.Command("sh", "-c", "sleep 100 & sleep 100")
+		cmd.SetNewProcessGroup(true)
+		cmd.Start()
+
+		cmd.Signal(syscall.SIGTERM)
+		func() {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Error("Wait did not panic for terminated process")
+				}
+			}()
+			cmd.Wait()
+		}()
+		waitGroupExited(t, cmd)
+	})
+
+	t.Run("Signal and KillTree panic before Start", func(t *testing.T) {
+		cmd := execmust.Command("true")
+		for name, f := range map[string]func(){"Signal": func() { cmd.Signal(syscall.SIGTERM) }, "KillTree": cmd.KillTree} {
+			func() {
+				defer func() {
+					err, _ := recover().(error)
+					if err == nil || !strings.Contains(err.Error(), "not started") {
+						t.Errorf("%s: expected not started error, got %v", name, err)
+					}
+				}()
+				f()
+			}()
+		}
+	})
+}
+
+func TestCancelSignal(t *testing.T) {
+	t.Run("default is os.Kill", func(t *testing.T) {
+		cmd := execmust.CommandContext(context.Background(), "true")
+		if cmd.CancelSignal() != os.Kill {
+			t.Errorf("expected os.Kill, got %v", cmd.CancelSignal())
+		}
+	})
+
+	t.Run("cancel signals the whole group", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cmd := execmust.CommandContext(ctx, "sh", "-c", "sleep 100 & sleep 100")
+		cmd.SetNewProcessGroup(true)
+		cmd.SetCancelSignal(syscall.SIGTERM)
+		cmd.Start()
+
+		cancel()
+		func() {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Error("Wait did not panic for canceled command")
+				}
+			}()
+			cmd.Wait()
+		}()
+		waitGroupExited(t, cmd)
+	})
+
+	t.Run("cancel escalates to os.Kill after WaitDelay", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cmd := execmust.CommandContext(ctx, "sh", "-c", "trap '' TERM; sleep 100 & sleep 100")
+		cmd.SetNewProcessGroup(true)
+		cmd.SetCancelSignal(syscall.SIGTERM)
+		cmd.SetWaitDelay(100 * time.Millisecond)
+		cmd.Start()
+		time.Sleep(100 * time.Millisecond)
+
+		cancel()
+		func() {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Error("Wait did not panic for canceled command")
+				}
+			}()
+			cmd.Wait()
+		}()
+		waitGroupExited(t, cmd)
+	})
+}
+
+func TestOutputString(t *testing.T) {
+	t.Run("trims output", func(t *testing.T) {
+		out := execmust.Command("sh", "-c", "echo '  hello  '; echo").OutputString()
+		if out != "hello" {
+			t.Errorf("expected 'hello', got %q", out)
+		}
+	})
+
+	t.Run("failure panics with stderr", func(t *testing.T) {
+		defer func() {
+			r := recover()
+			if r == nil {
+				t.Fatal("OutputString did not panic with failing command")
+			}
+			err, ok := r.(error)
+			if !ok {
+				t.Fatalf("expected error, got %T", r)
+			}
+			var exitErr *exec.ExitError
+			if !errors.As(err, &exitErr) {
+				t.Errorf("expected *exec.ExitError, got %v", err)
+			}
+			if !strings.Contains(err.Error(), "something went wrong") {
+				t.Errorf("expected stderr in error, got %q", err.Error())
+			}
+		}()
+		execmust.Command("sh", "-c", "echo 'something went wrong' >&2; exit 3").OutputString()
+	})
+}
+
+func TestOutputLines(t *testing.T) {
+	t.Run("splits lines", func(t *testing.T) {
+		lines := execmust.Command("printf", "a\\nb\\r\\nc\\n").OutputLines()
+		expected := []string{"a", "b", "c"}
+		if fmt.Sprint(lines) != fmt.Sprint(expected) {
+			t.Errorf("expected %q, got %q", expected, lines)
+		}
+	})
+
+	t.Run("empty output", func(t *testing.T) {
+		lines := execmust.Command("true").OutputLines()
+		if len(lines) != 0 {
+			t.Errorf("expected no lines, got %q", lines)
+		}
+	})
+}
+
+func TestOutputJSON(t *testing.T) {
+	type result struct {
+		Name string `json:"name"`
+		Age  int    `json:"age"`
+	}
+
+	t.Run("OutputJSON", func(t *testing.T) {
+		var v result
+		execmust.Command("echo", `{"name":"test","age":42}`).OutputJSON(&v)
+		if v.Name != "test" || v.Age != 42 {
+			t.Errorf("unexpected result: %+v", v)
+		}
+	})
+
+	t.Run("OutputJSONAs", func(t *testing.T) {
+		v := execmust.OutputJSONAs[result](execmust.Command("echo", `{"name":"test","age":42}`))
+		if v.Name != "test" || v.Age != 42 {
+			t.Errorf("unexpected result: %+v", v)
+		}
+	})
+
+	t.Run("invalid JSON panics", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("OutputJSONAs did not panic with invalid JSON")
+			}
+		}()
+		execmust.OutputJSONAs[result](execmust.Command("echo", "invalid json"))
+	})
+}
+
+func TestSplit(t *testing.T) {
+	env := []string{"NAME=world", "EMPTY=", "SPACED=a b"}
+	testcases := []struct {
+		in   string
+		want []string
+	}{
+		{in: "echo hello world", want: []string{"echo", "hello", "world"}},
+		{in: "  echo \t hello\n", want: []string{"echo", "hello"}},
+		{in: `echo 'hello world' "a b"`, want: []string{"echo", "hello world", "a b"}},
+		{in: `echo '' ""`, want: []string{"echo", "", ""}},
+		{in: `echo 'it''s' "say \"hi\"" \$HOME a\ b`, want: []string{"echo", "its", `say "hi"`, "$HOME", "a b"}},
+		{in: `echo "\n\\" '\n'`, want: []string{"echo", `\n\`, `\n`}},
+		{in: "echo a\\\nb", want: []string{"echo", "ab"}},
+		{in: `echo $NAME ${NAME}! "$NAME"`, want: []string{"echo", "world", "world!", "world"}},
+		{in: `echo $UNDEFINED $EMPTY "$EMPTY" x`, want: []string{"echo", "", "x"}},
+		{in: `echo $SPACED`, want: []string{"echo", "a b"}},
+		{in: `echo $ $1 100%`, want: []string{"echo", "$", "$1", "100%"}},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.in, func(t *testing.T) {
+			got := execmust.Split(tc.in, env)
+			if fmt.Sprintf("%q", got) != fmt.Sprintf("%q", tc.want) {
+				t.Errorf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+
+	for _, in := range []string{`echo 'a`, `echo "a`, `echo a\`, `echo ${A`, `echo ${1}`, `ls | grep a`, `echo a > f`, `echo $(id)`, "echo `id`", `a; b`} {
+		t.Run("panics/"+in, func(t *testing.T) {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("Split did not panic with %q", in)
+				}
+			}()
+			execmust.Split(in, nil)
+		})
+	}
+}
+
+func TestQuote(t *testing.T) {
+	args := []string{"git", "commit", "-m", "it's a \"test\"; rm -rf /", "", "$HOME", "a\nb", "path/to-file.txt"}
+	quoted := execmust.Quote(args...)
+	if !strings.HasPrefix(quoted, "git commit -m ") {
+		t.Errorf("safe words should not be quoted: %s", quoted)
+	}
+	got := execmust.Split(quoted, []string{"HOME=/root"})
+	if fmt.Sprintf("%q", got) != fmt.Sprintf("%q", args) {
+		t.Errorf("expected %q, got %q", args, got)
+	}
+}
+
+func TestSh(t *testing.T) {
+	t.Run("interpolated values are single words", func(t *testing.T) {
+		msg := "hello; echo injected $HOME 'x'"
+		cmd := execmust.Sh("echo -n %s", msg)
+		if args := cmd.Args(); len(args) != 3 || args[2] != msg {
+			t.Errorf("unexpected args: %q", args)
+		}
+		if out := cmd.OutputString(); out != msg {
+			t.Errorf("expected %q, got %q", msg, out)
+		}
+	})
+
+	t.Run("verbs", func(t *testing.T) {
+		cmd := execmust.Sh(`printf %s-%03d "%v %%" ''%s`, "a b", 7, true, "")
+		expected := []string{"printf", "a b-007", "true %", ""}
+		if fmt.Sprintf("%q", cmd.Args()) != fmt.Sprintf("%q", expected) {
+			t.Errorf("expected %q, got %q", expected, cmd.Args())
+		}
+	})
+
+	t.Run("verbs in quotes", func(t *testing.T) {
+		cmd := execmust.Sh(`echo '%s' 'a %s b' "%d" '%%' %q %-4q`, "x", "y z", 3, "q", "w")
+		expected := []string{"echo", "x", "a y z b", "3", "%", `"q"`, `"w" `}
+		if fmt.Sprintf("%q", cmd.Args()) != fmt.Sprintf("%q", expected) {
+			t.Errorf("expected %q, got %q", expected, cmd.Args())
+		}
+		if got := execmust.Split(`'%s'`, nil); len(got) != 1 || got[0] != "%s" {
+			t.Errorf("expected verbs to be literal in Split, got %q", got)
+		}
+	})
+
+	t.Run("argument mismatch panics", func(t *testing.T) {
+		for _, tc := range []struct {
+			format string
+			args   []any
+		}{
+			{format: "echo %s"},
+			{format: "echo '%s"},
+			{format: "echo", args: []any{"x"}},
+			{format: "echo %"},
+			{format: ""},
+		} {
+			func() {
+				defer func() {
+					if r := recover(); r == nil {
+						t.Errorf("Sh did not panic with %q %v", tc.format, tc.args)
+					}
+				}()
+				execmust.Sh(tc.format, tc.args...)
+			}()
+		}
+	})
+}
+
+func TestStreamLines(t *testing.T) {
+	t.Run("streams stdout and stderr", func(t *testing.T) {
+		var stdout, stderr []string
+		execmust.Command("sh", "-c", "echo a; echo b >&2; echo c; printf d").StreamLines(func(stream, line string) {
+			switch stream {
+			case "stdout":
+				stdout = append(stdout, line)
+			case "stderr":
+				stderr = append(stderr, line)
+			default:
+				t.Errorf("unexpected stream %q", stream)
+			}
+		})
+		if fmt.Sprint(stdout) != "[a c d]" {
+			t.Errorf("unexpected stdout lines: %q", stdout)
+		}
+		if fmt.Sprint(stderr) != "[b]" {
+			t.Errorf("unexpected stderr lines: %q", stderr)
+		}
+	})
+
+	t.Run("failure panics", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("StreamLines did not panic with failing command")
+			}
+		}()
+		execmust.Command("sh", "-c", "exit 1").StreamLines(func(stream, line string) {})
+	})
+
+	t.Run("panic in fn is raised by StreamLines", func(t *testing.T) {
+		var lines []string
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("expected panic boom, got %v", r)
+			}
+			if fmt.Sprint(lines) != "[a b]" {
+				t.Errorf("unexpected lines: %q", lines)
+			}
+		}()
+		execmust.Command("sh", "-c", "echo a; echo b; seq 100000").StreamLines(func(stream, line string) {
+			lines = append(lines, line)
+			if line == "b" {
+				panic("boom")
+			}
+		})
+	})
+}
+
+// failingWriter fails after limit writes.
+type failingWriter struct {
+	limit int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.limit == 0 {
+		return 0, errors.New("write failed")
+	}
+	w.limit--
+	return len(p), nil
+}
+
+// blockingWriter blocks its first write until unblock is closed.
+type blockingWriter struct {
+	once    sync.Once
+	started chan struct{}
+	unblock chan struct{}
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	w.once.Do(func() { close(w.started) })
+	<-w.unblock
+	return len(p), nil
+}
+
+func TestPrefixWriter(t *testing.T) {
+	t.Run("prefixes lines", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		w := execmust.PrefixWriter("[test] ", iomust.WriterOf(buf))
+		w.Write([]byte("a\nb"))
+		w.Write([]byte("c\n\nd"))
+		w.Close()
+		expected := "[test] a\n[test] bc\n[test] \n[test] d\n"
+		if buf.String() != expected {
+			t.Errorf("expected %q, got %q", expected, buf.String())
+		}
+	})
+
+	t.Run("Write returns the number of bytes of emitted lines on error", func(t *testing.T) {
+		w := execmust.PrefixWriter("", iomust.WriterOf(&failingWriter{limit: 1}))
+		n, err := w.Writer().Write([]byte("a\nb\nc"))
+		if n != 2 || err == nil {
+			t.Errorf("expected 2 bytes and an error, got %d, %v", n, err)
+		}
+	})
+
+	t.Run("with SetStdout and SetStderr", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		var wg sync.WaitGroup
+		for _, name := range []string{"x", "y"} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				w := execmust.PrefixWriter("["+name+"] ", iomust.WriterOf(buf))
+				defer w.Close()
+				cmd := execmust.Command("sh", "-c", "for i in 1 2 3 4 5; do echo out$i; echo err$i >&2; done")
+				cmd.SetStdout(w)
+				cmd.SetStderr(w)
+				cmd.Run()
+			}()
+		}
+		wg.Wait()
+		lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+		if len(lines) != 20 {
+			t.Fatalf("expected 20 lines, got %d: %q", len(lines), lines)
+		}
+		for _, line := range lines {
+			if !strings.HasPrefix(line, "[x] ") && !strings.HasPrefix(line, "[y] ") {
+				t.Errorf("line without prefix: %q", line)
+			}
+		}
+	})
+
+	t.Run("writers for different destinations do not block each other", func(t *testing.T) {
+		blocking := &blockingWriter{started: make(chan struct{}), unblock: make(chan struct{})}
+		defer close(blocking.unblock)
+		go func() {
+			w := execmust.PrefixWriter("[blocked] ", iomust.WriterOf(blocking))
+			w.Write([]byte("a\n"))
+		}()
+		<-blocking.started
+
+		done := make(chan struct{})
+		buf := &bytes.Buffer{}
+		go func() {
+			defer close(done)
+			w := execmust.PrefixWriter("[free] ", iomust.WriterOf(buf))
+			w.Write([]byte("b\n"))
+			w.Close()
+		}()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("writer was blocked by a writer for another destination")
+		}
+		if buf.String() != "[free] b\n" {
+			t.Errorf("expected %q, got %q", "[free] b\n", buf.String())
+		}
+	})
+}
+
+func TestPTY(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("pseudo-terminals are supported only on Linux")
+	}
+
+	t.Run("RunPTY", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		cmd := execmust.Command("sh", "-c", "tty; stty size; read line; echo got $line")
+		cmd.SetStdin(iomust.ReaderOf(strings.NewReader("input\n")))
+		cmd.SetStdout(iomust.WriterOf(buf))
+		cmd.RunPTY()
+
+		out := buf.String()
+		if !strings.Contains(out, "/dev/pts/") {
+			t.Errorf("expected terminal name in output, got %q", out)
+		}
+		if !strings.Contains(out, "24 80") {
+			t.Errorf("expected window size in output, got %q", out)
+		}
+		if !strings.Contains(out, "got input") {
+			t.Errorf("expected input echoed in output, got %q", out)
+		}
+	})
+
+	t.Run("RunPTY failure panics", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("RunPTY did not panic with failing command")
+			}
+		}()
+		execmust.Command("sh", "-c", "exit 1").RunPTY()
+	})
+
+	t.Run("Expect and Send", func(t *testing.T) {
+		cmd := execmust.Command("sh", "-c", `printf "name? "; read name; echo "hello, $name!"`)
+		p := cmd.StartPTY()
+		defer p.Close()
+		p.SetExpectTimeout(5 * time.Second)
+
+		p.Expect(regexp.MustCompile(`name\? `))
+		p.Send("world\n")
+		match := p.Expect(regexp.MustCompile(`hello, (\w+)!`))
+		if len(match) != 2 || match[1] != "world" {
+			t.Errorf("unexpected match: %q", match)
+		}
+		rest := iomust.ReadAll(p)
+		if string(rest) != "\r\n" {
+			t.Errorf("expected rest of output, got %q", rest)
+		}
+		cmd.Wait()
+	})
+
+	t.Run("SetSize", func(t *testing.T) {
+		cmd := execmust.Command("sh", "-c", "read line; stty size")
+		p := cmd.StartPTY()
+		defer p.Close()
+		p.SetSize(40, 120)
+		if rows, cols := p.Size(); rows != 40 || cols != 120 {
+			t.Errorf("expected 40x120, got %dx%d", rows, cols)
+		}
+		p.SetExpectTimeout(5 * time.Second)
+		p.Send("\n")
+		p.Expect(regexp.MustCompile(`40 120`))
+		cmd.Wait()
+	})
+
+	t.Run("Expect timeout panics", func(t *testing.T) {
+		cmd := execmust.Command("sleep", "10")
+		cmd.SetNewProcessGroup(true)
+		p := cmd.StartPTY()
+		defer p.Close()
+		defer cmd.KillTree()
+		p.SetExpectTimeout(100 * time.Millisecond)
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("Expect did not panic after timeout")
+			}
+		}()
+		p.Expect(regexp.MustCompile(`never`))
+	})
+}
@@ -0,0 +1,63 @@
+package execmust
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/Jumpaku/go-mustd"
+	"github.com/Jumpaku/go-mustd/encodingmust/jsonmust"
+)
+
+// OutputString runs the command and returns its standard output with leading and trailing white space removed.
+// Panics if an error occurs, including the captured standard error in the panic value.
+func (c *Cmd) OutputString() string {
+	return strings.TrimSpace(string(c.output()))
+}
+
+// OutputLines runs the command and returns its standard output split into lines without line terminators.
+// Panics if an error occurs, including the captured standard error in the panic value.
+func (c *Cmd) OutputLines() []string {
+	out := strings.TrimRight(string(c.output()), "\r\n")
+	if out == "" {
+		return []string{}
+	}
+	lines := strings.Split(out, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	return lines
+}
+
+// OutputJSON runs the command and decodes its standard output as JSON into v.
+// Panics if an error occurs, including the captured standard error in the panic value.
+func (c *Cmd) OutputJSON(v any) {
+	jsonmust.Unmarshal(c.output(), v)
+}
+
+// OutputJSONAs runs cmd and returns its standard output decoded as JSON into a value of type T.
+// Panics if an error occurs, including the captured standard error in the panic value.
+func OutputJSONAs[T any](cmd *Cmd) T {
+	var v T
+	cmd.OutputJSON(&v)
+	return v
+}
+
+// output runs the command and returns its standard output.
+// Panics if an error occurs, wrapping an exec.ExitError with the command line and the captured standard error.
+func (c *Cmd) output() []byte {
+	out, err := c.cmd.Output()
+	c.finishWait()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		stderr := bytes.TrimSpace(exitErr.Stderr)
+		if len(stderr) > 0 {
+			err = fmt.Errorf("%s: %w: %s", c.cmd.String(), err, stderr)
+		} else {
+			err = fmt.Errorf("%s: %w", c.cmd.String(), err)
+		}
+	}
+	return mustd.Must1(out, err)
+}
@@ -0,0 +1,106 @@
+package execmust
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/Jumpaku/go-mustd"
+)
+
+// SetNewProcessGroup specifies whether the command is started in a new process group.
+// Signals sent by Signal, KillTree and context cancellation then reach every process in the group.
+func (c *Cmd) SetNewProcessGroup(on bool) {
+	if c.cmd.SysProcAttr == nil {
+		c.cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	setNewProcessGroup(c.cmd.SysProcAttr, on)
+}
+
+// NewProcessGroup reports whether the command is started in a new process group.
+func (c *Cmd) NewProcessGroup() bool {
+	return c.cmd.SysProcAttr != nil && newProcessGroup(c.cmd.SysProcAttr)
+}
+
+// SetCancelSignal sets the signal sent when the context of a command created by CommandContext is done.
+// If sig is not os.Kill and WaitDelay is positive, os.Kill is sent after WaitDelay elapses.
+// The default signal is os.Kill.
+func (c *Cmd) SetCancelSignal(sig os.Signal) {
+	c.cancelSignal = sig
+}
+
+// CancelSignal returns the signal sent when the context of a command created by CommandContext is done.
+func (c *Cmd) CancelSignal() os.Signal {
+	if c.cancelSignal == nil {
+		return os.Kill
+	}
+	return c.cancelSignal
+}
+
+// Signal sends sig to the process, or to its whole process group if it was started in a new process group.
+// Panics if the command has not been started or an error occurs.
+func (c *Cmd) Signal(sig os.Signal) {
+	mustd.Must0(c.signal(sig))
+}
+
+// KillTree causes the process and, if it was started in a new process group, all processes in the group to exit immediately.
+// Panics if the command has not been started or an error occurs.
+func (c *Cmd) KillTree() {
+	mustd.Must0(c.signal(os.Kill))
+}
+
+func (c *Cmd) signal(sig os.Signal) error {
+	if c.cmd.Process == nil {
+		return fmt.Errorf("execmust: %s: not started", c.cmd.String())
+	}
+	if c.NewProcessGroup() {
+		return signalProcessGroup(c.cmd.Process.Pid, sig)
+	}
+	return c.cmd.Process.Signal(sig)
+}
+
+// cancel is the default Cancel function of commands created by CommandContext.
+func (c *Cmd) cancel() error {
+	sig := c.CancelSignal()
+	if err := c.signal(sig); err != nil {
+		return err
+	}
+	if sig != os.Kill && c.cmd.WaitDelay > 0 {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if !c.waited {
+			c.killAt = time.Now().Add(c.cmd.WaitDelay)
+			c.killTimer = time.AfterFunc(c.cmd.WaitDelay, c.escalate)
+		}
+	}
+	return nil
+}
+
+// escalate sends os.Kill after WaitDelay elapses since the cancel signal unless the command has been waited for.
+func (c *Cmd) escalate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.waited {
+		return
+	}
+	c.killed = true
+	// The processes may have exited already, so the error is ignored.
+	_ = c.signal(os.Kill)
+}
+
+// finishWait stops the pending os.Kill when the command has been waited for,
+// so that it is never sent to a process group whose ID may have been reused.
+func (c *Cmd) finishWait() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.waited = true
+	if c.killTimer == nil || c.killed {
+		return
+	}
+	c.killTimer.Stop()
+	if !time.Now().Before(c.killAt) {
+		// Wait returned because os/exec killed the process after WaitDelay, before the rest of the group was killed.
+		_ = c.signal(os.Kill)
+	}
+}
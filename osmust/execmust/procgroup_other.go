@@ -0,0 +1,23 @@
+//go:build !unix
+
+package execmust
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+func setNewProcessGroup(attr *syscall.SysProcAttr, on bool) {
+	if on {
+		panic(errors.ErrUnsupported)
+	}
+}
+
+func newProcessGroup(attr *syscall.SysProcAttr) bool {
+	return false
+}
+
+func signalProcessGroup(pid int, sig os.Signal) error {
+	return errors.ErrUnsupported
+}
@@ -0,0 +1,26 @@
+//go:build unix
+
+package execmust
+
+import (
+	"os"
+	"syscall"
+)
+
+func setNewProcessGroup(attr *syscall.SysProcAttr, on bool) {
+	attr.Setpgid = on
+	attr.Pgid = 0
+}
+
+func newProcessGroup(attr *syscall.SysProcAttr) bool {
+	// A new session also starts a new process group.
+	return attr.Setsid || attr.Setpgid && attr.Pgid == 0
+}
+
+func signalProcessGroup(pid int, sig os.Signal) error {
+	s, ok := sig.(syscall.Signal)
+	if !ok {
+		return &os.SyscallError{Syscall: "kill", Err: syscall.EINVAL}
+	}
+	return os.NewSyscallError("kill", syscall.Kill(-pid, s))
+}
@@ -0,0 +1,196 @@
+package execmust
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"syscall"
+	"time"
+
+	"github.com/Jumpaku/go-mustd"
+	"github.com/Jumpaku/go-mustd/iomust"
+)
+
+// PTY is the master side of a pseudo-terminal whose slave side is connected to a command started by StartPTY.
+// It reads the output of the command and writes the input of the command, and provides expect-style helpers for scripted interaction.
+type PTY struct {
+	conn    *ptyConn
+	timeout time.Duration
+}
+
+var _ iomust.ReadWriteCloser = (*PTY)(nil)
+
+// StartPTY starts the command in a new session whose controlling terminal is a newly allocated pseudo-terminal,
+// connecting the standard input, output and error of the command to it, and returns its master side.
+// The window size is initially 24 rows and 80 columns. Panics if an error occurs.
+// It is supported only on Linux.
+func (c *Cmd) StartPTY() *PTY {
+	master, slave := mustd.Must2(openPTY())
+	defer slave.Close()
+	if err := setPTYSize(master, 24, 80); err != nil {
+		master.Close()
+		panic(err)
+	}
+	if c.cmd.SysProcAttr == nil {
+		c.cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	setControllingTerminal(c.cmd.SysProcAttr)
+	c.cmd.Stdin, c.cmd.Stdout, c.cmd.Stderr = slave, slave, slave
+	if err := c.cmd.Start(); err != nil {
+		master.Close()
+		panic(err)
+	}
+	return &PTY{conn: &ptyConn{file: master}}
+}
+
+// RunPTY runs the command connected to a pseudo-terminal as StartPTY and waits for it to complete.
+// The standard input of the command is copied to the terminal and the output of the terminal is copied to the standard output of the command.
+// Panics if an error occurs. It is supported only on Linux.
+func (c *Cmd) RunPTY() {
+	stdin, stdout := c.cmd.Stdin, c.cmd.Stdout
+	if stdout == nil {
+		stdout = io.Discard
+	}
+	p := c.StartPTY()
+	defer p.Close()
+	if stdin != nil {
+		go func() {
+			// The terminal is closed when the command exits, so the error is ignored.
+			_, _ = io.Copy(p.conn, stdin)
+		}()
+	}
+	copied := make(chan error, 1)
+	go func() {
+		_, err := io.Copy(stdout, p.conn)
+		copied <- err
+	}()
+	err := c.cmd.Wait()
+	c.finishWait()
+	mustd.Must0(<-copied)
+	mustd.Must0(err)
+}
+
+// Read reads the output of the command from the terminal. Panics if an error occurs, except for io.EOF which is treated as a normal condition.
+func (p *PTY) Read(b []byte) (n int) {
+	return iomust.ReaderOf(p.conn).Read(b)
+}
+
+// Write writes the input of the command to the terminal. Panics if an error occurs.
+func (p *PTY) Write(b []byte) (n int) {
+	return mustd.Must1(p.conn.Write(b))
+}
+
+// Close closes the master side of the terminal. Panics if an error occurs.
+func (p *PTY) Close() {
+	mustd.Must0(p.conn.Close())
+}
+
+// Reader returns the master side of the terminal as an io.Reader.
+func (p *PTY) Reader() io.Reader {
+	return p.conn
+}
+
+// Writer returns the master side of the terminal as an io.Writer.
+func (p *PTY) Writer() io.Writer {
+	return p.conn
+}
+
+// Closer returns the master side of the terminal as an io.Closer.
+func (p *PTY) Closer() io.Closer {
+	return p.conn
+}
+
+// ReadWriteCloser returns the master side of the terminal as an io.ReadWriteCloser.
+func (p *PTY) ReadWriteCloser() io.ReadWriteCloser {
+	return p.conn
+}
+
+// SetSize sets the window size of the terminal. Panics if an error occurs.
+func (p *PTY) SetSize(rows, cols int) {
+	mustd.Must0(setPTYSize(p.conn.file, rows, cols))
+}
+
+// Size returns the window size of the terminal. Panics if an error occurs.
+func (p *PTY) Size() (rows, cols int) {
+	return mustd.Must2(getPTYSize(p.conn.file))
+}
+
+// SetExpectTimeout sets the duration Expect waits for the output. Zero means no timeout.
+func (p *PTY) SetExpectTimeout(timeout time.Duration) {
+	p.timeout = timeout
+}
+
+// ExpectTimeout returns the duration Expect waits for the output.
+func (p *PTY) ExpectTimeout() time.Duration {
+	return p.timeout
+}
+
+// Expect reads the output of the terminal until re matches it and returns the match and its submatches.
+// The output up to the end of the match is consumed, and the rest remains to be read.
+// Panics if the output ends, the timeout elapses or an error occurs before re matches.
+func (p *PTY) Expect(re *regexp.Regexp) []string {
+	var deadline time.Time
+	if p.timeout > 0 {
+		deadline = time.Now().Add(p.timeout)
+	}
+	mustd.Must0(p.conn.file.SetReadDeadline(deadline))
+	defer p.conn.file.SetReadDeadline(time.Time{})
+
+	chunk := make([]byte, 4096)
+	for {
+		if loc := re.FindSubmatchIndex(p.conn.buf); loc != nil {
+			match := make([]string, len(loc)/2)
+			for i := range match {
+				if loc[2*i] >= 0 {
+					match[i] = string(p.conn.buf[loc[2*i]:loc[2*i+1]])
+				}
+			}
+			p.conn.buf = p.conn.buf[loc[1]:]
+			return match
+		}
+		n, err := p.conn.readFile(chunk)
+		p.conn.buf = append(p.conn.buf, chunk[:n]...)
+		if err != nil {
+			panic(fmt.Errorf("execmust: expect %q: %w: output %q", re, err, p.conn.buf))
+		}
+	}
+}
+
+// Send writes s to the terminal as the input of the command. Panics if an error occurs.
+func (p *PTY) Send(s string) {
+	iomust.WriteString(p, s)
+}
+
+// ptyConn implements io.ReadWriteCloser on the master side of a pseudo-terminal.
+type ptyConn struct {
+	file *os.File
+	// buf holds the output read but not consumed by Expect.
+	buf []byte
+}
+
+func (c *ptyConn) Read(p []byte) (n int, err error) {
+	if len(c.buf) > 0 {
+		n = copy(p, c.buf)
+		c.buf = c.buf[n:]
+		return n, nil
+	}
+	return c.readFile(p)
+}
+
+// readFile reads from the terminal, reporting io.EOF after the slave side is closed.
+func (c *ptyConn) readFile(p []byte) (n int, err error) {
+	n, err = c.file.Read(p)
+	if err != nil && isPTYClosed(err) {
+		err = io.EOF
+	}
+	return n, err
+}
+
+func (c *ptyConn) Write(p []byte) (n int, err error) {
+	return c.file.Write(p)
+}
+
+func (c *ptyConn) Close() error {
+	return c.file.Close()
+}
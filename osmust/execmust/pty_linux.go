@@ -0,0 +1,76 @@
+package execmust
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"syscall"
+	"unsafe"
+)
+
+func openPTY() (master, slave *os.File, err error) {
+	master, err = os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	var unlock int32
+	var n uint32
+	if err = ioctl(master, syscall.TIOCSPTLCK, uintptr(unsafe.Pointer(&unlock))); err == nil {
+		err = ioctl(master, syscall.TIOCGPTN, uintptr(unsafe.Pointer(&n)))
+	}
+	if err == nil {
+		slave, err = os.OpenFile("/dev/pts/"+strconv.FormatUint(uint64(n), 10), os.O_RDWR|syscall.O_NOCTTY, 0)
+	}
+	if err != nil {
+		master.Close()
+		return nil, nil, err
+	}
+	return master, slave, nil
+}
+
+// winsize corresponds to struct winsize in <sys/ioctl.h>.
+type winsize struct {
+	rows, cols, xpixel, ypixel uint16
+}
+
+func setPTYSize(f *os.File, rows, cols int) error {
+	ws := winsize{rows: uint16(rows), cols: uint16(cols)}
+	return ioctl(f, syscall.TIOCSWINSZ, uintptr(unsafe.Pointer(&ws)))
+}
+
+func getPTYSize(f *os.File) (rows, cols int, err error) {
+	var ws winsize
+	err = ioctl(f, syscall.TIOCGWINSZ, uintptr(unsafe.Pointer(&ws)))
+	return int(ws.rows), int(ws.cols), err
+}
+
+func setControllingTerminal(attr *syscall.SysProcAttr) {
+	// A new session starts a new process group, in which setpgid would fail.
+	attr.Setpgid = false
+	attr.Setsid = true
+	attr.Setctty = true
+	attr.Ctty = 0
+}
+
+// isPTYClosed reports whether err is returned by reading the master side after the slave side is closed.
+func isPTYClosed(err error) bool {
+	return errors.Is(err, syscall.EIO)
+}
+
+// ioctl calls ioctl on f without putting it into blocking mode as f.Fd does.
+func ioctl(f *os.File, req uint, arg uintptr) error {
+	conn, err := f.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var errno syscall.Errno
+	if err := conn.Control(func(fd uintptr) {
+		_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, fd, uintptr(req), arg)
+	}); err != nil {
+		return err
+	}
+	if errno != 0 {
+		return os.NewSyscallError("ioctl", errno)
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+//go:build !linux
+
+package execmust
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+func openPTY() (master, slave *os.File, err error) {
+	return nil, nil, errors.ErrUnsupported
+}
+
+func setPTYSize(f *os.File, rows, cols int) error {
+	return errors.ErrUnsupported
+}
+
+func getPTYSize(f *os.File) (rows, cols int, err error) {
+	return 0, 0, errors.ErrUnsupported
+}
+
+func setControllingTerminal(attr *syscall.SysProcAttr) {}
+
+func isPTYClosed(err error) bool {
+	return false
+}
@@ -0,0 +1,282 @@
+package execmust
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// Sh returns a Cmd to execute the program with the arguments given by a shell-like command line, without invoking a shell.
+// The command line is the format string, which is split into words by Split with the environment of the current process.
+// Each verb in format, including one inside single or double quotes, is replaced with the corresponding argument formatted by the fmt package
+// with its flags, width and precision, and the replacement is always a part of a single word and is never split, quoted or expanded.
+// Because replacements are never split, %s suffices to pass a value as a word, and %q produces a Go-quoted string as the fmt package does.
+// Panics if format is malformed or does not match the arguments.
+func Sh(format string, a ...any) *Cmd {
+	args := mustSplit(format, os.Environ(), a)
+	return Command(args[0], args[1:]...)
+}
+
+// ShContext is like Sh but includes a context.
+func ShContext(ctx context.Context, format string, a ...any) *Cmd {
+	args := mustSplit(format, os.Environ(), a)
+	return CommandContext(ctx, args[0], args[1:]...)
+}
+
+// Split splits s into words like a POSIX shell, without invoking a shell.
+// It interprets white space as a separator and supports single quotes, double quotes, backslash escapes,
+// and parameter expansions $NAME and ${NAME} looking up env given in the form "key=value".
+// Undefined parameters expand to the empty string and expansions are never split into multiple words.
+// Panics if s is malformed or contains unsupported shell syntax such as pipes, redirections or command substitutions.
+func Split(s string, env []string) []string {
+	p := newWordSplitter(s, env, nil, false)
+	p.split()
+	return p.words
+}
+
+// Quote returns a command line that is split into args by Split.
+// Each argument is quoted with single quotes only if necessary, so the result is also suitable for logging.
+func Quote(args ...string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = quoteWord(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+func quoteWord(s string) string {
+	if s == "" {
+		return "''"
+	}
+	safe := true
+	for _, r := range s {
+		if !isSafeRune(r) {
+			safe = false
+			break
+		}
+	}
+	if safe {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func isSafeRune(r rune) bool {
+	return 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' || strings.ContainsRune("_@%+=:,./-", r)
+}
+
+func mustSplit(format string, env []string, args []any) []string {
+	p := newWordSplitter(format, env, args, true)
+	p.split()
+	if len(p.words) == 0 {
+		panic(fmt.Errorf("execmust: empty command line %q", format))
+	}
+	return p.words
+}
+
+// wordSplitter splits a shell-like command line into words.
+type wordSplitter struct {
+	src    string
+	pos    int
+	env    map[string]string
+	args   []any
+	format bool
+	argIdx int
+	words  []string
+	word   strings.Builder
+	inWord bool
+}
+
+func newWordSplitter(src string, env []string, args []any, format bool) *wordSplitter {
+	m := map[string]string{}
+	for _, kv := range env {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			m[k] = v
+		}
+	}
+	return &wordSplitter{src: src, env: m, args: args, format: format}
+}
+
+func (p *wordSplitter) errorf(pos int, format string, a ...any) {
+	panic(fmt.Errorf("execmust: %s at offset %d in %q", fmt.Sprintf(format, a...), pos, p.src))
+}
+
+func (p *wordSplitter) write(s string) {
+	p.word.WriteString(s)
+	p.inWord = true
+}
+
+func (p *wordSplitter) endWord() {
+	if p.inWord {
+		p.words = append(p.words, p.word.String())
+	}
+	p.word.Reset()
+	p.inWord = false
+}
+
+func (p *wordSplitter) split() {
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			p.endWord()
+			p.pos++
+		case c == '\'':
+			p.singleQuoted()
+		case c == '"':
+			p.doubleQuoted()
+		case c == '\\':
+			p.escaped("")
+		case c == '$':
+			p.expand(false)
+		case c == '%' && p.format:
+			p.verb()
+		case strings.IndexByte("|&;<>()`", c) >= 0:
+			p.errorf(p.pos, "unsupported shell syntax %q", c)
+		default:
+			_, size := utf8.DecodeRuneInString(p.src[p.pos:])
+			p.write(p.src[p.pos : p.pos+size])
+			p.pos += size
+		}
+	}
+	p.endWord()
+	if p.format && p.argIdx < len(p.args) {
+		p.errorf(p.pos, "too many arguments: %d verbs for %d arguments", p.argIdx, len(p.args))
+	}
+}
+
+// escaped handles a backslash at the current position.
+// If special is not empty, the backslash escapes only the characters in special and a newline, as in double quotes.
+func (p *wordSplitter) escaped(special string) {
+	start := p.pos
+	p.pos++
+	if p.pos >= len(p.src) {
+		p.errorf(start, "unterminated backslash escape")
+	}
+	if p.src[p.pos] == '\n' {
+		p.pos++
+		return
+	}
+	_, size := utf8.DecodeRuneInString(p.src[p.pos:])
+	next := p.src[p.pos : p.pos+size]
+	if special != "" && !strings.Contains(special, next) {
+		p.write(`\`)
+	}
+	p.write(next)
+	p.pos += size
+}
+
+// singleQuoted handles single quotes at the current position, in which only verbs are interpreted.
+func (p *wordSplitter) singleQuoted() {
+	start := p.pos
+	p.pos++
+	p.inWord = true
+	for {
+		if p.pos >= len(p.src) {
+			p.errorf(start, "unterminated single quote")
+		}
+		switch c := p.src[p.pos]; {
+		case c == '\'':
+			p.pos++
+			return
+		case c == '%' && p.format:
+			p.verb()
+		default:
+			p.write(p.src[p.pos : p.pos+1])
+			p.pos++
+		}
+	}
+}
+
+func (p *wordSplitter) doubleQuoted() {
+	start := p.pos
+	p.pos++
+	p.inWord = true
+	for {
+		if p.pos >= len(p.src) {
+			p.errorf(start, "unterminated double quote")
+		}
+		c := p.src[p.pos]
+		switch {
+		case c == '"':
+			p.pos++
+			return
+		case c == '\\':
+			p.escaped("$`\"\\")
+		case c == '$':
+			p.expand(true)
+		case c == '%' && p.format:
+			p.verb()
+		case c == '`':
+			p.errorf(p.pos, "unsupported command substitution")
+		default:
+			p.write(p.src[p.pos : p.pos+1])
+			p.pos++
+		}
+	}
+}
+
+func isNameByte(c byte, first bool) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || !first && '0' <= c && c <= '9'
+}
+
+// expand handles a parameter expansion at the current position.
+func (p *wordSplitter) expand(quoted bool) {
+	start := p.pos
+	p.pos++
+	var name string
+	switch {
+	case p.pos < len(p.src) && p.src[p.pos] == '{':
+		end := strings.IndexByte(p.src[p.pos:], '}')
+		if end < 0 {
+			p.errorf(start, "unterminated parameter expansion")
+		}
+		name = p.src[p.pos+1 : p.pos+end]
+		if name == "" || !isNameByte(name[0], true) || strings.IndexFunc(name, func(r rune) bool { return r > utf8.RuneSelf || !isNameByte(byte(r), false) }) >= 0 {
+			p.errorf(start, "bad substitution %q", p.src[start:p.pos+end+1])
+		}
+		p.pos += end + 1
+	case p.pos < len(p.src) && p.src[p.pos] == '(':
+		p.errorf(start, "unsupported command substitution")
+	case p.pos < len(p.src) && isNameByte(p.src[p.pos], true):
+		end := p.pos + 1
+		for end < len(p.src) && isNameByte(p.src[end], false) {
+			end++
+		}
+		name = p.src[p.pos:end]
+		p.pos = end
+	default:
+		p.write("$")
+		return
+	}
+	if value := p.env[name]; value != "" || quoted {
+		p.write(value)
+	}
+}
+
+// verb handles a formatting verb at the current position.
+func (p *wordSplitter) verb() {
+	start := p.pos
+	p.pos++
+	if p.pos < len(p.src) && p.src[p.pos] == '%' {
+		p.write("%")
+		p.pos++
+		return
+	}
+	for p.pos < len(p.src) && strings.IndexByte("+-# 0123456789.", p.src[p.pos]) >= 0 {
+		p.pos++
+	}
+	if p.pos >= len(p.src) || !isNameByte(p.src[p.pos], true) || p.src[p.pos] == '_' {
+		p.errorf(start, "bad verb %q", p.src[start:min(p.pos+1, len(p.src))])
+	}
+	p.pos++
+	if p.argIdx >= len(p.args) {
+		p.errorf(start, "missing argument for %q", p.src[start:p.pos])
+	}
+	arg := p.args[p.argIdx]
+	p.argIdx++
+	// The replacement always forms a part of a single word, even if it is empty.
+	p.write(fmt.Sprintf(p.src[start:p.pos], arg))
+}
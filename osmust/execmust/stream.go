@@ -0,0 +1,152 @@
+package execmust
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/Jumpaku/go-mustd"
+	"github.com/Jumpaku/go-mustd/iomust"
+)
+
+// StreamLines runs the command and calls fn with each line written to the standard output and the standard error,
+// where stream is "stdout" or "stderr" and line does not include the line terminator.
+// Calls of fn are serialized, and a final line without a line terminator is also passed to fn. Panics if an error occurs.
+// If fn panics, the rest of the output is discarded and StreamLines panics with the same value after the command exits.
+func (c *Cmd) StreamLines(fn func(stream string, line string)) {
+	var mu sync.Mutex
+	var panicked any
+	newWriter := func(stream string) *lineWriter {
+		return &lineWriter{emit: func(line []byte) error {
+			mu.Lock()
+			defer mu.Unlock()
+			if panicked != nil {
+				return nil
+			}
+			// fn is called on a goroutine of os/exec copying the output, so a panic is recovered to be raised by StreamLines.
+			defer func() {
+				if r := recover(); r != nil {
+					panicked = r
+				}
+			}()
+			fn(stream, strings.TrimSuffix(strings.TrimSuffix(string(line), "\n"), "\r"))
+			return nil
+		}}
+	}
+	stdout, stderr := newWriter("stdout"), newWriter("stderr")
+	c.cmd.Stdout, c.cmd.Stderr = stdout, stderr
+	err := c.cmd.Run()
+	c.finishWait()
+	mustd.Must0(stdout.Close())
+	mustd.Must0(stderr.Close())
+	if panicked != nil {
+		panic(panicked)
+	}
+	mustd.Must0(err)
+}
+
+// destinationLock serializes lines written to a destination by writers returned by PrefixWriter.
+type destinationLock struct {
+	sync.Mutex
+	refs int
+}
+
+var (
+	destinationLocksMu sync.Mutex
+	destinationLocks   = map[io.Writer]*destinationLock{}
+)
+
+// acquireDestinationLock returns the lock shared by the open writers returned by PrefixWriter for dst,
+// and a function releasing it. A destination of an uncomparable type gets a lock of its own.
+func acquireDestinationLock(dst io.Writer) (*destinationLock, func()) {
+	if !reflect.ValueOf(dst).Comparable() {
+		return &destinationLock{}, func() {}
+	}
+	destinationLocksMu.Lock()
+	defer destinationLocksMu.Unlock()
+	l, ok := destinationLocks[dst]
+	if !ok {
+		l = &destinationLock{}
+		destinationLocks[dst] = l
+	}
+	l.refs++
+	var once sync.Once
+	return l, func() {
+		once.Do(func() {
+			destinationLocksMu.Lock()
+			defer destinationLocksMu.Unlock()
+			if l.refs--; l.refs == 0 {
+				delete(destinationLocks, dst)
+			}
+		})
+	}
+}
+
+// PrefixWriter returns a WriteCloser that writes each line to w with prefix prepended.
+// Written data are buffered until a line terminator is written,
+// so lines from concurrent writers returned by PrefixWriter for the same w are never interleaved,
+// while writers for different destinations do not block each other.
+// Close writes the buffered incomplete line if any, but does not close w.
+func PrefixWriter(prefix string, w iomust.Writer) iomust.WriteCloser {
+	dst := w.Writer()
+	lock, release := acquireDestinationLock(dst)
+	return iomust.WriteCloserOf(&lineWriter{
+		emit: func(line []byte) error {
+			lock.Lock()
+			defer lock.Unlock()
+			buf := make([]byte, 0, len(prefix)+len(line)+1)
+			buf = append(append(buf, prefix...), line...)
+			if !bytes.HasSuffix(buf, []byte("\n")) {
+				buf = append(buf, '\n')
+			}
+			_, err := dst.Write(buf)
+			return err
+		},
+		release: release,
+	})
+}
+
+// lineWriter is an io.WriteCloser that calls emit with each line including the line terminator.
+type lineWriter struct {
+	mu   sync.Mutex
+	buf  []byte
+	emit func(line []byte) error
+	// release is called by Close if not nil.
+	release func()
+}
+
+var _ io.WriteCloser = (*lineWriter)(nil)
+
+func (w *lineWriter) Write(p []byte) (n int, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for n < len(p) {
+		i := bytes.IndexByte(p[n:], '\n')
+		if i < 0 {
+			w.buf = append(w.buf, p[n:]...)
+			return len(p), nil
+		}
+		if err := w.emit(append(w.buf, p[n:n+i+1]...)); err != nil {
+			return n, err
+		}
+		w.buf = w.buf[:0]
+		n += i + 1
+	}
+	return n, nil
+}
+
+func (w *lineWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.release != nil {
+		defer w.release()
+	}
+	if len(w.buf) == 0 {
+		return nil
+	}
+	line := w.buf
+	w.buf = nil
+	return w.emit(line)
+}